@@ -665,6 +665,188 @@ func (m *GetMoneyAckData) GetMoney() *UserMoney {
 	return nil
 }
 
+// 連線後 microservice 送出的第一個命令
+type HandshakeData struct {
+	Service              string   `protobuf:"bytes,1,opt,name=service,proto3" json:"service,omitempty"`
+	Instance             string   `protobuf:"bytes,2,opt,name=instance,proto3" json:"instance,omitempty"`
+	Version              uint32   `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	MinVersion           uint32   `protobuf:"varint,4,opt,name=minVersion,proto3" json:"minVersion,omitempty"`
+	CommandSets          []string `protobuf:"bytes,5,rep,name=commandSets,proto3" json:"commandSets,omitempty"`
+	Token                string   `protobuf:"bytes,6,opt,name=token,proto3" json:"token,omitempty"`
+	Timestamp            int64    `protobuf:"varint,7,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Nonce                string   `protobuf:"bytes,8,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Signature            []byte   `protobuf:"bytes,9,opt,name=signature,proto3" json:"signature,omitempty"`
+	Features             []string `protobuf:"bytes,10,rep,name=features,proto3" json:"features,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HandshakeData) Reset()         { *m = HandshakeData{} }
+func (m *HandshakeData) String() string { return proto.CompactTextString(m) }
+func (*HandshakeData) ProtoMessage()    {}
+func (*HandshakeData) Descriptor() ([]byte, []int) {
+	return fileDescriptor_190592c454e29f61, []int{10}
+}
+func (m *HandshakeData) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HandshakeData.Unmarshal(m, b)
+}
+func (m *HandshakeData) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HandshakeData.Marshal(b, m, deterministic)
+}
+func (m *HandshakeData) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HandshakeData.Merge(m, src)
+}
+func (m *HandshakeData) XXX_Size() int {
+	return xxx_messageInfo_HandshakeData.Size(m)
+}
+func (m *HandshakeData) XXX_DiscardUnknown() {
+	xxx_messageInfo_HandshakeData.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HandshakeData proto.InternalMessageInfo
+
+func (m *HandshakeData) GetService() string {
+	if m != nil {
+		return m.Service
+	}
+	return ""
+}
+
+func (m *HandshakeData) GetInstance() string {
+	if m != nil {
+		return m.Instance
+	}
+	return ""
+}
+
+func (m *HandshakeData) GetVersion() uint32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *HandshakeData) GetMinVersion() uint32 {
+	if m != nil {
+		return m.MinVersion
+	}
+	return 0
+}
+
+func (m *HandshakeData) GetCommandSets() []string {
+	if m != nil {
+		return m.CommandSets
+	}
+	return nil
+}
+
+func (m *HandshakeData) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+func (m *HandshakeData) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *HandshakeData) GetNonce() string {
+	if m != nil {
+		return m.Nonce
+	}
+	return ""
+}
+
+func (m *HandshakeData) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+func (m *HandshakeData) GetFeatures() []string {
+	if m != nil {
+		return m.Features
+	}
+	return nil
+}
+
+// AgencyService 對 HandshakeData 的回應
+type HandshakeAckData struct {
+	Accepted             bool     `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Version              uint32   `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	CommandSets          []string `protobuf:"bytes,3,rep,name=commandSets,proto3" json:"commandSets,omitempty"`
+	Reason               string   `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	Features             []string `protobuf:"bytes,5,rep,name=features,proto3" json:"features,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HandshakeAckData) Reset()         { *m = HandshakeAckData{} }
+func (m *HandshakeAckData) String() string { return proto.CompactTextString(m) }
+func (*HandshakeAckData) ProtoMessage()    {}
+func (*HandshakeAckData) Descriptor() ([]byte, []int) {
+	return fileDescriptor_190592c454e29f61, []int{11}
+}
+func (m *HandshakeAckData) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HandshakeAckData.Unmarshal(m, b)
+}
+func (m *HandshakeAckData) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HandshakeAckData.Marshal(b, m, deterministic)
+}
+func (m *HandshakeAckData) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HandshakeAckData.Merge(m, src)
+}
+func (m *HandshakeAckData) XXX_Size() int {
+	return xxx_messageInfo_HandshakeAckData.Size(m)
+}
+func (m *HandshakeAckData) XXX_DiscardUnknown() {
+	xxx_messageInfo_HandshakeAckData.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HandshakeAckData proto.InternalMessageInfo
+
+func (m *HandshakeAckData) GetAccepted() bool {
+	if m != nil {
+		return m.Accepted
+	}
+	return false
+}
+
+func (m *HandshakeAckData) GetVersion() uint32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *HandshakeAckData) GetCommandSets() []string {
+	if m != nil {
+		return m.CommandSets
+	}
+	return nil
+}
+
+func (m *HandshakeAckData) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *HandshakeAckData) GetFeatures() []string {
+	if m != nil {
+		return m.Features
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterEnum("agency.AgencyToMicro", AgencyToMicro_name, AgencyToMicro_value)
 	proto.RegisterEnum("agency.MicroToAgency", MicroToAgency_name, MicroToAgency_value)
@@ -679,62 +861,75 @@ func init() {
 	proto.RegisterType((*Money)(nil), "agency.Money")
 	proto.RegisterType((*UserMoney)(nil), "agency.UserMoney")
 	proto.RegisterType((*GetMoneyAckData)(nil), "agency.GetMoneyAckData")
+	proto.RegisterType((*HandshakeData)(nil), "agency.HandshakeData")
+	proto.RegisterType((*HandshakeAckData)(nil), "agency.HandshakeAckData")
 }
 
 func init() { proto.RegisterFile("AgencyProtocol.proto", fileDescriptor_190592c454e29f61) }
 
 var fileDescriptor_190592c454e29f61 = []byte{
-	// 818 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x55, 0x4d, 0x8f, 0xe3, 0x44,
-	0x10, 0x5d, 0xc7, 0xf9, 0x9a, 0xca, 0x26, 0xf1, 0xf4, 0x7c, 0xac, 0xb5, 0xcc, 0x21, 0xb2, 0x10,
-	0x84, 0x91, 0x18, 0x50, 0x40, 0x48, 0x1c, 0x4d, 0xc6, 0xcc, 0x64, 0x49, 0xec, 0xd0, 0x76, 0x06,
-	0xc1, 0x25, 0x32, 0x4e, 0x4f, 0xc6, 0xbb, 0x89, 0x3b, 0x6a, 0x77, 0x06, 0xe5, 0xc8, 0xdf, 0xe0,
-	0xc2, 0x9d, 0x5f, 0x89, 0xba, 0x1c, 0x3b, 0x9e, 0x08, 0xb1, 0xb7, 0xae, 0xf7, 0x5e, 0x75, 0xbd,
-	0xae, 0xaa, 0xc4, 0x70, 0x6e, 0x2f, 0x59, 0x12, 0xed, 0xa6, 0x82, 0x4b, 0x1e, 0xf1, 0xd5, 0xcd,
-	0x46, 0x1d, 0x48, 0x3d, 0x44, 0xd4, 0xfa, 0x5b, 0x83, 0xd6, 0x2c, 0x65, 0x62, 0x2a, 0xf8, 0x63,
-	0xbc, 0x62, 0xc4, 0x84, 0xc6, 0x8a, 0x2f, 0xe3, 0x64, 0xb4, 0x30, 0xb5, 0x9e, 0xd6, 0x3f, 0xa1,
-	0x79, 0x48, 0xde, 0x42, 0x33, 0x89, 0xa3, 0x0f, 0x49, 0xb8, 0x66, 0x66, 0x05, 0xa9, 0x22, 0x26,
-	0x57, 0x70, 0x12, 0x46, 0x11, 0xdf, 0x26, 0x72, 0xb4, 0x30, 0xf5, 0x9e, 0xd6, 0x6f, 0xd3, 0x03,
-	0x40, 0x7a, 0xd0, 0xc2, 0xa3, 0xd8, 0x0d, 0xf9, 0x82, 0x99, 0x55, 0x4c, 0x2e, 0x43, 0xea, 0xee,
-	0x55, 0x98, 0x2c, 0xb7, 0xe1, 0x92, 0x99, 0xb5, 0x9e, 0xd6, 0xaf, 0xd1, 0x22, 0xb6, 0xfe, 0xa9,
-	0x00, 0x28, 0x87, 0xbe, 0x0c, 0xe5, 0x36, 0x25, 0x16, 0xbc, 0x5e, 0xb0, 0x0d, 0x4f, 0x63, 0x19,
-	0xc4, 0x6b, 0x96, 0xa2, 0xcb, 0x36, 0x7d, 0x81, 0x91, 0x4f, 0xa1, 0xbd, 0x8f, 0xed, 0xb5, 0xaa,
-	0x82, 0x7e, 0x35, 0xfa, 0x12, 0x54, 0x45, 0x9f, 0xe3, 0xcd, 0x98, 0x3d, 0xb3, 0x15, 0x7a, 0xae,
-	0xd1, 0x22, 0x56, 0x96, 0x37, 0x82, 0x3d, 0xe4, 0x74, 0x15, 0xe9, 0x32, 0xa4, 0x14, 0x6b, 0x9e,
-	0xc8, 0xa7, 0xd5, 0x6e, 0x1a, 0xa6, 0x29, 0xba, 0xd6, 0x69, 0x19, 0x52, 0x0a, 0xc9, 0x65, 0xb8,
-	0xda, 0x7b, 0xa8, 0xa3, 0x87, 0x32, 0x44, 0xfa, 0xd0, 0x5d, 0x85, 0xa9, 0x9c, 0xa8, 0xa4, 0xbd,
-	0xaa, 0x81, 0xaa, 0x63, 0x98, 0x7c, 0x06, 0x9d, 0x68, 0x2b, 0xca, 0xc2, 0x26, 0x0a, 0x8f, 0x50,
-	0xeb, 0x3d, 0xb4, 0x55, 0xaf, 0xc6, 0x6a, 0x66, 0xb7, 0xa1, 0x0c, 0xc9, 0x97, 0xd0, 0xd8, 0x64,
-	0xa3, 0xc5, 0x4e, 0xb5, 0x06, 0x67, 0x37, 0xd9, 0xe4, 0x6f, 0x4a, 0x53, 0xa7, 0xb9, 0x86, 0x5c,
-	0x43, 0x3d, 0xc5, 0x3e, 0x63, 0xcb, 0x5a, 0x03, 0x52, 0x56, 0x67, 0x13, 0xa0, 0x7b, 0x85, 0xf5,
-	0x15, 0x74, 0x15, 0x6a, 0x67, 0x73, 0xc6, 0x6a, 0x2f, 0xf6, 0x40, 0x3b, 0xda, 0x03, 0xeb, 0x2f,
-	0x0d, 0x3a, 0x77, 0xe1, 0x9a, 0xf9, 0x4c, 0x3c, 0x33, 0x81, 0x09, 0x26, 0x34, 0xe4, 0x13, 0x5b,
-	0xb3, 0x42, 0x9e, 0x87, 0xe4, 0x12, 0xea, 0xcb, 0x10, 0x89, 0x0a, 0x12, 0xfb, 0x48, 0x95, 0x40,
-	0x89, 0xab, 0xf6, 0x50, 0xc7, 0x55, 0x3a, 0x00, 0x6a, 0xa6, 0x4a, 0x87, 0x64, 0xb6, 0x67, 0x45,
-	0xac, 0x32, 0x97, 0x82, 0x6f, 0x37, 0x48, 0xd6, 0xb2, 0xcc, 0x02, 0xb0, 0xbe, 0x86, 0xf3, 0x83,
-	0x37, 0x97, 0xcb, 0xf8, 0x71, 0xf7, 0xff, 0x0e, 0x2d, 0x0f, 0x2e, 0xd4, 0xfb, 0xdf, 0xf1, 0x38,
-	0x19, 0xb3, 0xf0, 0x99, 0xa9, 0xf4, 0x8f, 0x3c, 0xea, 0x45, 0x7f, 0x2a, 0xc7, 0xfd, 0xf9, 0x1e,
-	0x6a, 0x13, 0x9e, 0xb0, 0x9d, 0x7a, 0x45, 0x24, 0xd8, 0x22, 0x96, 0xa3, 0x04, 0x6f, 0xd0, 0x69,
-	0x11, 0x93, 0x73, 0xa8, 0xfd, 0x11, 0x27, 0xa3, 0x04, 0xd3, 0x75, 0x9a, 0x05, 0xd6, 0x77, 0x70,
-	0xa2, 0xbc, 0x64, 0xe9, 0x5f, 0x40, 0x73, 0x23, 0xf8, 0x86, 0x09, 0xb9, 0x33, 0xb5, 0x9e, 0xde,
-	0x6f, 0x0d, 0xda, 0xf9, 0x18, 0x51, 0x40, 0x0b, 0xda, 0x7a, 0x0f, 0xdd, 0x3b, 0x26, 0x11, 0xb5,
-	0xa3, 0x0f, 0xe8, 0xfe, 0x06, 0x74, 0xb1, 0xff, 0x5d, 0x75, 0x06, 0x57, 0x79, 0xe2, 0x70, 0x2b,
-	0x84, 0x3a, 0x0c, 0x9f, 0xc2, 0x64, 0xc9, 0xf6, 0x9b, 0xa0, 0x84, 0xe4, 0x73, 0xa8, 0xad, 0x55,
-	0xfe, 0x7e, 0x63, 0x4e, 0xcb, 0x1b, 0x93, 0x95, 0xcb, 0xf8, 0xeb, 0x3f, 0x2b, 0xd0, 0xce, 0xfe,
-	0x8b, 0x02, 0x3e, 0x89, 0x23, 0xc1, 0xc9, 0x6b, 0x68, 0xda, 0x83, 0xc9, 0xdc, 0x9d, 0x8d, 0xc7,
-	0xc6, 0x2b, 0x42, 0xa0, 0xa3, 0xa2, 0x99, 0xef, 0xd0, 0xf9, 0xd8, 0xbb, 0x1b, 0xb9, 0x86, 0x46,
-	0xce, 0xa0, 0x5b, 0xc6, 0xbc, 0x59, 0x60, 0x54, 0xc8, 0x5b, 0xb8, 0x54, 0xe0, 0x9d, 0x3d, 0x71,
-	0xe6, 0xbe, 0x43, 0x1f, 0x14, 0x67, 0xcf, 0xdc, 0xe1, 0xbd, 0xa1, 0x93, 0x2b, 0x30, 0x8f, 0x39,
-	0xff, 0x7e, 0x16, 0xdc, 0x7a, 0xbf, 0xb8, 0x46, 0x95, 0x5c, 0x02, 0x29, 0xae, 0x7b, 0xe7, 0x8d,
-	0x5c, 0xd4, 0x19, 0x35, 0xf2, 0x06, 0xce, 0x0e, 0x65, 0x1c, 0xfb, 0xc1, 0xc9, 0x88, 0x7a, 0x5e,
-	0x0a, 0x89, 0xd9, 0xf4, 0xd6, 0x0e, 0x9c, 0xb9, 0x1f, 0xd8, 0xc1, 0xcc, 0x37, 0x1a, 0xe4, 0x13,
-	0x78, 0x73, 0xcc, 0x4d, 0xa9, 0xf7, 0xe3, 0x68, 0xec, 0x18, 0x4d, 0x72, 0x01, 0xa7, 0xe8, 0xc3,
-	0x09, 0xe6, 0x13, 0xcf, 0x75, 0x7e, 0x9d, 0xdb, 0xc3, 0x9f, 0x8c, 0x93, 0xeb, 0x6f, 0xa1, 0x8d,
-	0x4f, 0x0f, 0x78, 0xd6, 0x09, 0xd5, 0x82, 0xc9, 0xc0, 0xce, 0x5b, 0x70, 0x01, 0xa7, 0x2a, 0x3a,
-	0x64, 0x51, 0xe7, 0x67, 0x43, 0xbb, 0xfe, 0x0d, 0xce, 0xff, 0xab, 0xff, 0xa4, 0x09, 0x55, 0x97,
-	0x27, 0xcc, 0x78, 0x45, 0x5a, 0xd0, 0xf0, 0xb7, 0x51, 0xc4, 0xd2, 0xd4, 0xd0, 0x08, 0x40, 0xdd,
-	0xe5, 0xaa, 0xfd, 0x46, 0x85, 0x74, 0x00, 0xbc, 0xad, 0xf4, 0x1e, 0x71, 0x12, 0x86, 0x4e, 0xba,
-	0xd0, 0xf2, 0x77, 0xa9, 0x64, 0x6b, 0x47, 0x08, 0x2e, 0x8c, 0xea, 0x0f, 0x95, 0x7b, 0xed, 0xf7,
-	0x3a, 0x7e, 0x13, 0xbe, 0xf9, 0x37, 0x00, 0x00, 0xff, 0xff, 0x4f, 0xa7, 0x2d, 0xf8, 0x2b, 0x06,
+	// 994 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x55, 0x5b, 0x6f, 0xeb, 0x44,
+	0x17, 0x3d, 0x8e, 0x73, 0xdd, 0x69, 0x5a, 0x77, 0x7a, 0x39, 0x56, 0xbf, 0xea, 0x53, 0x64, 0x21,
+	0x08, 0x95, 0x28, 0x28, 0x20, 0x24, 0x1e, 0x4d, 0x6b, 0xda, 0x1c, 0x72, 0x63, 0x92, 0x14, 0xc1,
+	0x4b, 0x34, 0x38, 0xd3, 0xd4, 0xa7, 0xf1, 0x8c, 0x35, 0x9e, 0x04, 0xe5, 0x91, 0xbf, 0xc1, 0x03,
+	0x3c, 0xf1, 0xc2, 0xaf, 0x44, 0x33, 0xbe, 0xc4, 0x09, 0x08, 0xde, 0x66, 0xad, 0xbd, 0xb7, 0x67,
+	0xcd, 0xda, 0x7b, 0xc6, 0x70, 0xee, 0x2e, 0x29, 0xf3, 0xb7, 0x63, 0xc1, 0x25, 0xf7, 0xf9, 0xea,
+	0x36, 0x52, 0x0b, 0x54, 0x25, 0x9a, 0x75, 0x7e, 0x37, 0xa0, 0x39, 0x8b, 0xa9, 0x18, 0x0b, 0xfe,
+	0x1c, 0xac, 0x28, 0xb2, 0xa1, 0xb6, 0xe2, 0xcb, 0x80, 0xf5, 0x16, 0xb6, 0xd1, 0x36, 0x3a, 0x0d,
+	0x9c, 0x41, 0x74, 0x05, 0x75, 0x16, 0xf8, 0xaf, 0x8c, 0x84, 0xd4, 0x2e, 0xe9, 0x50, 0x8e, 0xd1,
+	0x35, 0x34, 0x88, 0xef, 0xf3, 0x35, 0x93, 0xbd, 0x85, 0x6d, 0xb6, 0x8d, 0x4e, 0x0b, 0xef, 0x08,
+	0xd4, 0x86, 0xa6, 0x5e, 0x8a, 0xed, 0x1d, 0x5f, 0x50, 0xbb, 0xac, 0x8b, 0x8b, 0x94, 0xfa, 0xf6,
+	0x8a, 0xb0, 0xe5, 0x9a, 0x2c, 0xa9, 0x5d, 0x69, 0x1b, 0x9d, 0x0a, 0xce, 0xb1, 0xf3, 0x67, 0x09,
+	0x40, 0x29, 0x9c, 0x48, 0x22, 0xd7, 0x31, 0x72, 0xe0, 0x68, 0x41, 0x23, 0x1e, 0x07, 0x72, 0x1a,
+	0x84, 0x34, 0xd6, 0x2a, 0x5b, 0x78, 0x8f, 0x43, 0x1f, 0x40, 0x2b, 0xc5, 0x6e, 0xa8, 0x76, 0xd1,
+	0x7a, 0x0d, 0xbc, 0x4f, 0xaa, 0x4d, 0x37, 0x41, 0xd4, 0xa7, 0x1b, 0xba, 0xd2, 0x9a, 0x2b, 0x38,
+	0xc7, 0x4a, 0x72, 0x24, 0xe8, 0x53, 0x16, 0x2e, 0xeb, 0x70, 0x91, 0x52, 0x19, 0x21, 0x67, 0xf2,
+	0x65, 0xb5, 0x1d, 0x93, 0x38, 0xd6, 0xaa, 0x4d, 0x5c, 0xa4, 0x54, 0x86, 0xe4, 0x92, 0xac, 0x52,
+	0x0d, 0x55, 0xad, 0xa1, 0x48, 0xa1, 0x0e, 0x9c, 0xac, 0x48, 0x2c, 0x07, 0xaa, 0x28, 0xcd, 0xaa,
+	0xe9, 0xac, 0x43, 0x1a, 0x7d, 0x08, 0xc7, 0xfe, 0x5a, 0x14, 0x13, 0xeb, 0x3a, 0xf1, 0x80, 0x75,
+	0xde, 0x43, 0x4b, 0x79, 0xd5, 0x57, 0x3d, 0xbb, 0x27, 0x92, 0xa0, 0x4f, 0xa0, 0x16, 0x25, 0xad,
+	0xd5, 0x4e, 0x35, 0xbb, 0x67, 0xb7, 0x49, 0xe7, 0x6f, 0x0b, 0x5d, 0xc7, 0x59, 0x0e, 0xba, 0x81,
+	0x6a, 0xac, 0x7d, 0xd6, 0x96, 0x35, 0xbb, 0xa8, 0x98, 0x9d, 0x74, 0x00, 0xa7, 0x19, 0xce, 0xa7,
+	0x70, 0xa2, 0x58, 0x37, 0xe9, 0xb3, 0xde, 0x6d, 0x6f, 0x0e, 0x8c, 0x83, 0x39, 0x70, 0x7e, 0x35,
+	0xe0, 0xf8, 0x81, 0x84, 0x74, 0x42, 0xc5, 0x86, 0x0a, 0x5d, 0x60, 0x43, 0x4d, 0xbe, 0xd0, 0x90,
+	0xe6, 0xe9, 0x19, 0x44, 0x97, 0x50, 0x5d, 0x12, 0x1d, 0x28, 0xe9, 0x40, 0x8a, 0xd4, 0x16, 0x3a,
+	0x65, 0xa8, 0xe6, 0xd0, 0xd4, 0xa3, 0xb4, 0x23, 0x54, 0x4f, 0x97, 0x24, 0x59, 0xa7, 0x73, 0x96,
+	0x63, 0x55, 0xb9, 0x14, 0x7c, 0x1d, 0xe9, 0x60, 0x25, 0xa9, 0xcc, 0x09, 0xe7, 0x33, 0x38, 0xdf,
+	0x69, 0x1b, 0x72, 0x19, 0x3c, 0x6f, 0xff, 0x5d, 0xa1, 0x33, 0x82, 0x0b, 0x75, 0xfe, 0x77, 0x3c,
+	0x60, 0x7d, 0x4a, 0x36, 0x54, 0x95, 0xff, 0xc7, 0xa1, 0xf6, 0xfc, 0x29, 0x1d, 0xfa, 0xf3, 0x15,
+	0x54, 0x06, 0x9c, 0xd1, 0xad, 0x3a, 0x85, 0x2f, 0xe8, 0x22, 0x90, 0x3d, 0xa6, 0xbf, 0x60, 0xe2,
+	0x1c, 0xa3, 0x73, 0xa8, 0xfc, 0x1c, 0xb0, 0x1e, 0xd3, 0xe5, 0x26, 0x4e, 0x80, 0xf3, 0x25, 0x34,
+	0x94, 0x96, 0xa4, 0xfc, 0x63, 0xa8, 0x47, 0x82, 0x47, 0x54, 0xc8, 0xad, 0x6d, 0xb4, 0xcd, 0x4e,
+	0xb3, 0xdb, 0xca, 0xda, 0xa8, 0x13, 0x70, 0x1e, 0x76, 0xde, 0xc3, 0xc9, 0x03, 0x95, 0x9a, 0x75,
+	0xfd, 0x57, 0xad, 0xfe, 0x16, 0x4c, 0x91, 0xde, 0xab, 0xe3, 0xee, 0x75, 0x56, 0x78, 0xb7, 0x16,
+	0x42, 0x2d, 0xee, 0x5e, 0x08, 0x5b, 0xd2, 0x74, 0x12, 0x54, 0x22, 0xfa, 0x08, 0x2a, 0xa1, 0xaa,
+	0x4f, 0x27, 0xe6, 0xb4, 0x38, 0x31, 0xc9, 0x76, 0x49, 0xdc, 0xf9, 0xa3, 0x04, 0xad, 0x47, 0xc2,
+	0x16, 0xf1, 0x0b, 0x79, 0xcd, 0x8d, 0x8a, 0xa9, 0xd8, 0x04, 0x3e, 0xcd, 0x1e, 0x9b, 0x14, 0x2a,
+	0x07, 0x02, 0x16, 0x4b, 0xc2, 0xfc, 0xfc, 0xb1, 0xc9, 0xb0, 0xaa, 0xda, 0x50, 0x11, 0x07, 0x9c,
+	0xa5, 0x4f, 0x4d, 0x06, 0xd1, 0xff, 0x01, 0xc2, 0x80, 0x3d, 0xa5, 0xc1, 0xb2, 0x0e, 0x16, 0x98,
+	0xe4, 0x21, 0x0a, 0x43, 0xc2, 0x16, 0x13, 0x2a, 0xd5, 0x9d, 0x35, 0x93, 0x87, 0x28, 0xa7, 0x94,
+	0xbb, 0x92, 0xbf, 0x52, 0xa6, 0x6f, 0x6b, 0x03, 0x27, 0x40, 0xcf, 0x9c, 0x7a, 0x58, 0x24, 0x09,
+	0x23, 0x7d, 0x43, 0x4d, 0xbc, 0x23, 0x54, 0x0d, 0xe3, 0x4a, 0x68, 0x3d, 0xa9, 0xd1, 0x40, 0xd5,
+	0xc4, 0xc1, 0x92, 0x11, 0xb9, 0x16, 0xd4, 0x6e, 0xb4, 0x8d, 0xce, 0x11, 0xde, 0x11, 0xea, 0x7c,
+	0xcf, 0x54, 0x2f, 0x63, 0x1b, 0xb4, 0x8c, 0x1c, 0x3b, 0xbf, 0x19, 0x60, 0xe5, 0x3e, 0x65, 0x5d,
+	0xb9, 0x82, 0x3a, 0xf1, 0x7d, 0x1a, 0x49, 0x9a, 0x0c, 0x55, 0x1d, 0xe7, 0xb8, 0x68, 0x48, 0x69,
+	0xdf, 0x90, 0x83, 0x03, 0x9b, 0x7f, 0x3f, 0xf0, 0x25, 0x54, 0x05, 0x25, 0x71, 0x6a, 0x57, 0x03,
+	0xa7, 0x68, 0x4f, 0x60, 0x65, 0x5f, 0xe0, 0xcd, 0x2f, 0x25, 0x68, 0x25, 0x3f, 0x95, 0x29, 0x1f,
+	0x04, 0xbe, 0xe0, 0xe8, 0x08, 0xea, 0x6e, 0x77, 0x30, 0x1f, 0xce, 0xfa, 0x7d, 0xeb, 0x0d, 0x42,
+	0x70, 0xac, 0xd0, 0x6c, 0xe2, 0xe1, 0x79, 0x7f, 0xf4, 0xd0, 0x1b, 0x5a, 0x06, 0x3a, 0x83, 0x93,
+	0x22, 0x37, 0x9a, 0x4d, 0xad, 0x12, 0xba, 0x82, 0x4b, 0x45, 0x3e, 0xb8, 0x03, 0x6f, 0x3e, 0xf1,
+	0xf0, 0x93, 0x8a, 0xb9, 0xb3, 0xe1, 0xdd, 0xa3, 0x65, 0xa2, 0x6b, 0xb0, 0x0f, 0x63, 0x93, 0xc7,
+	0xd9, 0xf4, 0x7e, 0xf4, 0xfd, 0xd0, 0x2a, 0xa3, 0x4b, 0x40, 0xf9, 0xe7, 0xde, 0x8d, 0x7a, 0x43,
+	0x9d, 0x67, 0x55, 0xd0, 0x5b, 0x38, 0xdb, 0x6d, 0xe3, 0xb9, 0x4f, 0x5e, 0x12, 0xa8, 0x66, 0x5b,
+	0xe9, 0xc0, 0x6c, 0x7c, 0xef, 0x4e, 0xbd, 0xf9, 0x64, 0xea, 0x4e, 0x67, 0x13, 0xab, 0x86, 0xfe,
+	0x07, 0x6f, 0x0f, 0x63, 0x63, 0x3c, 0xfa, 0xa6, 0xd7, 0xf7, 0xac, 0x3a, 0xba, 0x80, 0x53, 0xad,
+	0xc3, 0x9b, 0xce, 0x07, 0xa3, 0xa1, 0xf7, 0xc3, 0xdc, 0xbd, 0xfb, 0xd6, 0x6a, 0xdc, 0x7c, 0x01,
+	0x2d, 0x7d, 0xf4, 0x29, 0x4f, 0x9c, 0x50, 0x16, 0x0c, 0xba, 0x6e, 0x66, 0xc1, 0x05, 0x9c, 0x2a,
+	0xb4, 0xab, 0xc2, 0xde, 0x77, 0x96, 0x71, 0xf3, 0x23, 0x9c, 0xff, 0xd3, 0x45, 0x42, 0x75, 0x28,
+	0x0f, 0x39, 0xa3, 0xd6, 0x1b, 0xd4, 0x84, 0xda, 0x64, 0xed, 0xfb, 0x34, 0x8e, 0x2d, 0x03, 0x01,
+	0x54, 0x87, 0x5c, 0xdd, 0x23, 0xab, 0x84, 0x8e, 0x01, 0x46, 0x6b, 0x39, 0x7a, 0xd6, 0x57, 0xca,
+	0x32, 0xd1, 0x09, 0x34, 0x27, 0xdb, 0x58, 0xd2, 0xd0, 0x13, 0x82, 0x0b, 0xab, 0xfc, 0x75, 0xe9,
+	0xd1, 0xf8, 0xa9, 0xaa, 0x7f, 0xee, 0x9f, 0xff, 0x35, 0x00, 0xd1, 0x88, 0x01, 0x84, 0xf4, 0x07,
 	0x00, 0x00,
 }
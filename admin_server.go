@@ -0,0 +1,435 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//	Constants
+//------------------------------------------------------------------------------
+
+const (
+	// 預設的 admin 位置，只開放本機
+	defaultAdminAddress = "127.0.0.1:8700"
+	// log stream 訂閱的 channel 容量
+	logStreamBuffer = 256
+)
+
+//------------------------------------------------------------------------------
+//	Structure declare
+//------------------------------------------------------------------------------
+
+type (
+	// AdminServer : 以 JSON 提供 PoolManager、Job、Manager 與 log 查詢的 http 服務
+	//	GET  /workers                 worker 狀態 (GetAdminInfos)
+	//	GET  /handlers                handler 執行統計 (GetHandlerMetrics)
+	//	GET  /tasks/blocked           阻擋中的 Task 與排隊狀況
+	//	GET  /orders/{key}            OrderData 的佇列內容，需先 SetOrderLookup
+	//	GET  /jobs                    Job 列表
+	//	POST /jobs/{id}/{action}      action: suspend, resume, cancel
+	//	GET  /managers                已登記 Manager 的物件數量
+	//	GET  /connectors              已登記 Connector 的連線統計
+	//	GET  /logs?index=N            分頁取得 log，index 省略則取最後一頁
+	//	                              可加上 level=WARN, contains=xxx 過濾
+	//	GET  /logs/stream             持續輸出新的 log，可加上 level, contains 過濾
+	//	GET  /logs/levels             全域與各模組的 log 等級
+	//	POST /logs/levels?spec=S      設定 log 等級，ex: spec=connector=debug,*=info
+	//	GET  /metrics                 Prometheus metrics
+	AdminServer struct {
+		address     string
+		mux         *http.ServeMux
+		server      *http.Server
+		closeSignal chan struct{}
+		running     *InterlockBool
+		orderLookup func(key string) *OrderData
+	}
+
+	// JobOutData : Job 輸出顯示用
+	JobOutData struct {
+		ID       int64  // 工作編號
+		Name     string // function 名稱
+		State    string // 目前狀態
+		Interval string // 執行週期
+	}
+
+	// ManagerOutData : Manager 輸出顯示用
+	ManagerOutData struct {
+		Name  string // 登記名稱
+		Items int    // 管理中的物件數量
+	}
+
+	// ConnectorOutData : Connector 輸出顯示用
+	ConnectorOutData struct {
+		Name string // 登記名稱
+		ConnectorStats
+	}
+
+	// LogOutData : log 分頁輸出
+	LogOutData struct {
+		Index int      // 此頁第一行的位置
+		Next  int      // 下一頁的位置
+		Lines []string // log 內容
+	}
+
+	adminError struct {
+		Error string
+	}
+)
+
+//------------------------------------------------------------------------------
+//	Public Methods
+//------------------------------------------------------------------------------
+
+// NewAdminServer : AdminServer object creator.
+// @param	address	listen 位置，空字串則使用 127.0.0.1:8700
+func NewAdminServer(address string) *AdminServer {
+	if address == "" {
+		address = defaultAdminAddress
+	}
+	a := &AdminServer{
+		address:     address,
+		mux:         http.NewServeMux(),
+		closeSignal: make(chan struct{}),
+		running:     NewInterlockBool(false),
+	}
+	a.mux.HandleFunc("/workers", a.onWorkers)
+	a.mux.HandleFunc("/handlers", a.onHandlers)
+	a.mux.HandleFunc("/tasks/blocked", a.onBlockedTasks)
+	a.mux.HandleFunc("/orders/", a.onOrderQueue)
+	a.mux.HandleFunc("/jobs", a.onJobs)
+	a.mux.HandleFunc("/jobs/", a.onJobAction)
+	a.mux.HandleFunc("/managers", a.onManagers)
+	a.mux.HandleFunc("/connectors", a.onConnectors)
+	a.mux.HandleFunc("/logs", a.onLogs)
+	a.mux.HandleFunc("/logs/stream", a.onLogStream)
+	a.mux.HandleFunc("/logs/levels", a.onLogLevels)
+	a.mux.Handle("/metrics", MetricsHandler())
+	return a
+}
+
+// Handle : 加入應用程式自訂的 admin 路徑
+func (a *AdminServer) Handle(pattern string, handler http.Handler) {
+	a.mux.Handle(pattern, handler)
+}
+
+// SetOrderLookup : 設定以鍵值找出 OrderData 的方法，給 /orders/{key} 使用
+// @param	lookup	找不到時回傳 nil
+func (a *AdminServer) SetOrderLookup(lookup func(key string) *OrderData) {
+	a.orderLookup = lookup
+}
+
+// Handler : 取得 admin 的 http.Handler，可掛在應用程式自己的 http server 上
+func (a *AdminServer) Handler() http.Handler {
+	return a.mux
+}
+
+// Address : 取得 listen 位置
+func (a *AdminServer) Address() string {
+	return a.address
+}
+
+// Start : 開始 listen，服務在獨立的 goroutine 中執行
+func (a *AdminServer) Start() error {
+	if a.running.Exchange(true) {
+		return errors.New("admin server already started")
+	}
+	listener, err := net.Listen("tcp", a.address)
+	if err != nil {
+		a.running.False()
+		logger(adminLog).Error("AdminServer:Start: listen failed. ADDR=%s, ERR=%s", a.address, err.Error())
+		return err
+	}
+	a.address = listener.Addr().String()
+	a.server = &http.Server{Handler: a.mux}
+	go func() {
+		if err := a.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logger(adminLog).Error("AdminServer:Start: serve failed. ERR=%s", err.Error())
+		}
+	}()
+	logger(adminLog).Notice("AdminServer:Start: listen. ADDR=%s", a.address)
+	return nil
+}
+
+// Shutdown : 關閉 admin 服務
+func (a *AdminServer) Shutdown() {
+	if !a.running.Exchange(false) {
+		logger(adminLog).Error("AdminServer:Shutdown: not start.")
+		return
+	}
+	close(a.closeSignal)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := a.server.Shutdown(ctx); err != nil {
+		logger(adminLog).Error("AdminServer:Shutdown: error occur. ERR=%s", err.Error())
+	}
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+func (a *AdminServer) onWorkers(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	writeJSON(w, http.StatusOK, PoolManager.GetAdminInfos())
+}
+
+func (a *AdminServer) onHandlers(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	writeJSON(w, http.StatusOK, PoolManager.GetHandlerMetrics())
+}
+
+func (a *AdminServer) onBlockedTasks(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	writeJSON(w, http.StatusOK, PoolManager.GetBlockedTasks())
+}
+
+func (a *AdminServer) onOrderQueue(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	if a.orderLookup == nil {
+		writeJSON(w, http.StatusNotFound, adminError{"order lookup not set"})
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, "/orders/")
+	order := a.orderLookup(key)
+	if order == nil {
+		writeJSON(w, http.StatusNotFound, adminError{"order not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, order.DumpQueue())
+}
+
+func (a *AdminServer) onJobs(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	jobs := PoolManager.GetJobs()
+	res := make([]JobOutData, len(jobs))
+	for i, job := range jobs {
+		res[i] = makeJobOutData(job)
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (a *AdminServer) onJobAction(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+	// /jobs/{id}/{action}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/jobs/"), "/"), "/")
+	if len(parts) != 2 {
+		writeJSON(w, http.StatusNotFound, adminError{"unknown path"})
+		return
+	}
+	id, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, adminError{"invalid job id"})
+		return
+	}
+	job := PoolManager.FindJob(id)
+	if job == nil {
+		writeJSON(w, http.StatusNotFound, adminError{"job not found"})
+		return
+	}
+	switch parts[1] {
+	case "suspend":
+		job.Suspend()
+	case "resume":
+		job.Resume()
+	case "cancel":
+		job.Cancel()
+	default:
+		writeJSON(w, http.StatusBadRequest, adminError{"unknown action"})
+		return
+	}
+	logger(adminLog).Notice("AdminServer:onJobAction: JOB=%d, NAME=%s, ACTION=%s", id, job.Name(), parts[1])
+	writeJSON(w, http.StatusOK, makeJobOutData(job))
+}
+
+func (a *AdminServer) onManagers(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	managers := registeredManagers()
+	res := make([]ManagerOutData, len(managers))
+	for i, m := range managers {
+		res[i] = ManagerOutData{m.name, m.manager.Len()}
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (a *AdminServer) onConnectors(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	connectors := registeredConnectors()
+	res := make([]ConnectorOutData, len(connectors))
+	for i, c := range connectors {
+		res[i] = ConnectorOutData{c.name, c.connector.GetStats()}
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (a *AdminServer) onLogs(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	query, ok := parseLogQuery(w, r)
+	if !ok {
+		return
+	}
+	query.From = -1
+	if str := r.URL.Query().Get("index"); str != "" {
+		var err error
+		if query.From, err = strconv.Atoi(str); err != nil || query.From < -1 {
+			writeJSON(w, http.StatusBadRequest, adminError{"invalid index"})
+			return
+		}
+	}
+	entries, next := QueryLogs(query)
+	lines := make([]string, len(entries))
+	for i, entry := range entries {
+		lines[i] = entry.Line
+	}
+	index := next
+	if len(entries) > 0 {
+		index = entries[0].Seq
+	}
+	writeJSON(w, http.StatusOK, LogOutData{index, next, lines})
+}
+
+func (a *AdminServer) onLogStream(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	query, ok := parseLogQuery(w, r)
+	if !ok {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, adminError{"streaming unsupported"})
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	entries, cancel := SubscribeLogs(logStreamBuffer)
+	defer cancel()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-a.closeSignal:
+			return
+		case entry := <-entries:
+			if query.Level > 0 && entry.Level > query.Level {
+				continue
+			}
+			if query.Contains != "" && !strings.Contains(entry.Line, query.Contains) {
+				continue
+			}
+			if _, err := w.Write([]byte(entry.Line + "\n")); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func (a *AdminServer) onLogLevels(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if err := ApplyLogLevels(r.FormValue("spec")); err != nil {
+			writeJSON(w, http.StatusBadRequest, adminError{err.Error()})
+			return
+		}
+		logger(adminLog).Notice("AdminServer:onLogLevels: log levels changed. SPEC=%s", r.FormValue("spec"))
+	default:
+		w.Header().Set("Allow", http.MethodGet+", "+http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, adminError{"method not allowed"})
+		return
+	}
+	writeJSON(w, http.StatusOK, GetModuleLogLevels())
+}
+
+// parseLogQuery : 解析 level 與 contains 參數
+func parseLogQuery(w http.ResponseWriter, r *http.Request) (LogQuery, bool) {
+	var query LogQuery
+	values := r.URL.Query()
+	if str := values.Get("level"); str != "" {
+		level, err := ParseLogLevel(str)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, adminError{err.Error()})
+			return query, false
+		}
+		query.Level = level
+	}
+	query.Contains = values.Get("contains")
+	return query, true
+}
+
+//------------------------------------------------------------------------------
+
+func makeJobOutData(job *Job) JobOutData {
+	return JobOutData{
+		ID:       job.ID(),
+		Name:     job.Name(),
+		State:    job.GetStatus().String(),
+		Interval: job.Interval().String(),
+	}
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeJSON(w, http.StatusMethodNotAllowed, adminError{"method not allowed"})
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		logger(adminLog).Error("AdminServer:writeJSON: encode failed. ERR=%s", err.Error())
+	}
+}
@@ -0,0 +1,216 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//	Constants
+//------------------------------------------------------------------------------
+
+const (
+	// 預設容許的 handshake 時間誤差
+	defaultAuthMaxSkew = 30 * time.Second
+	// bearer token 的 Authorization 前綴
+	bearerPrefix = "Bearer "
+)
+
+//------------------------------------------------------------------------------
+//	Variables
+//------------------------------------------------------------------------------
+
+var (
+	// ErrUnauthorized : 驗證失敗
+	ErrUnauthorized = errors.New("unauthorized")
+)
+
+//------------------------------------------------------------------------------
+//	Structure declare
+//------------------------------------------------------------------------------
+
+type (
+	// ConnectorAuth : Connector 端的驗證設定
+	ConnectorAuth struct {
+		Header http.Header // 連線時額外帶的 request header
+		Token  string      // bearer token，放在 Authorization header，handshake 沒有指定 token 時也會帶上
+		Secret []byte      // HMAC shared secret，設定後 handshake 會帶 timestamp、nonce 與簽章
+	}
+
+	// ServerAuth : ConnectorServer 端的驗證設定
+	ServerAuth struct {
+		Tokens  []string                    // 接受的 bearer token，handshake 有帶 token 時也必須在其中；空則不檢查
+		Secret  []byte                      // HMAC shared secret，設定後 handshake 必須帶有正確的簽章
+		MaxSkew time.Duration               // handshake timestamp 容許的誤差，0 則為 30 秒
+		Verify  func(r *http.Request) error // 額外的 request 檢查，ex: 自訂 header
+		nonces  map[string]time.Time        // 已使用的 nonce -> 到期時間
+		lock    sync.Mutex
+	}
+)
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+// header : 連線時要帶的 request header
+func (a *ConnectorAuth) header() http.Header {
+	header := http.Header{}
+	for k, v := range a.Header {
+		header[k] = append([]string(nil), v...)
+	}
+	if a.Token != "" {
+		header.Set("Authorization", bearerPrefix+a.Token)
+	}
+	return header
+}
+
+// sign : 為 handshake 帶上 token (未指定時)，並加上 timestamp、nonce 與簽章
+func (a *ConnectorAuth) sign(req *HandshakeData) {
+	if req.Token == "" {
+		req.Token = a.Token
+	}
+	if len(a.Secret) == 0 {
+		return
+	}
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	req.Timestamp = time.Now().Unix()
+	req.Nonce = hex.EncodeToString(nonce)
+	req.Signature = handshakeSignature(a.Secret, req)
+}
+
+//------------------------------------------------------------------------------
+
+// verifyRequest : upgrade 之前檢查 bearer token 與自訂條件
+func (a *ServerAuth) verifyRequest(r *http.Request) error {
+	if len(a.Tokens) > 0 {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, bearerPrefix) {
+			return errors.New("missing bearer token")
+		}
+		if !a.validToken(strings.TrimPrefix(auth, bearerPrefix)) {
+			return errors.New("invalid bearer token")
+		}
+	}
+	if a.Verify != nil {
+		return a.Verify(r)
+	}
+	return nil
+}
+
+// verifyHandshake : 檢查 handshake 的 token、簽章、時間與 nonce。
+// bearer token 已在 verifyRequest 檢查過，handshake 有帶 token 時也必須是接受的 token
+func (a *ServerAuth) verifyHandshake(req *HandshakeData) error {
+	if len(a.Tokens) > 0 && req.Token != "" && !a.validToken(req.Token) {
+		return errors.New("invalid handshake token")
+	}
+	if len(a.Secret) == 0 {
+		return nil
+	}
+	if len(req.Signature) == 0 || req.Nonce == "" {
+		return errors.New("missing signature")
+	}
+	if !hmac.Equal(req.Signature, handshakeSignature(a.Secret, req)) {
+		return errors.New("invalid signature")
+	}
+	skew := a.MaxSkew
+	if skew <= 0 {
+		skew = defaultAuthMaxSkew
+	}
+	now := time.Now()
+	stamp := time.Unix(req.Timestamp, 0)
+	if stamp.Before(now.Add(-skew)) || stamp.After(now.Add(skew)) {
+		return errors.New("timestamp out of range")
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.nonces == nil {
+		a.nonces = make(map[string]time.Time)
+	}
+	for nonce, expire := range a.nonces {
+		if expire.Before(now) {
+			delete(a.nonces, nonce)
+		}
+	}
+	if _, ok := a.nonces[req.Nonce]; ok {
+		return errors.New("nonce reused")
+	}
+	// 超過時間誤差後 timestamp 檢查就會失敗，不需要再記住
+	a.nonces[req.Nonce] = stamp.Add(skew)
+	return nil
+}
+
+// validToken : 是否為接受的 token，以固定時間比較
+func (a *ServerAuth) validToken(token string) bool {
+	ok := false
+	for _, t := range a.Tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+			ok = true
+		}
+	}
+	return ok
+}
+
+//------------------------------------------------------------------------------
+
+// handshakeSignature : HMAC-SHA256(secret, 除了 signature 以外的所有欄位)
+func handshakeSignature(secret []byte, req *HandshakeData) []byte {
+	mac := hmac.New(sha256.New, secret)
+	field := func(str string) {
+		var size [4]byte
+		binary.LittleEndian.PutUint32(size[:], uint32(len(str)))
+		mac.Write(size[:])
+		mac.Write([]byte(str))
+	}
+	var num [8]byte
+	field(req.Service)
+	field(req.Instance)
+	binary.LittleEndian.PutUint32(num[:4], req.Version)
+	binary.LittleEndian.PutUint32(num[4:], req.MinVersion)
+	mac.Write(num[:])
+	binary.LittleEndian.PutUint32(num[:4], uint32(len(req.CommandSets)))
+	mac.Write(num[:4])
+	for _, set := range req.CommandSets {
+		field(set)
+	}
+	field(req.Token)
+	binary.LittleEndian.PutUint64(num[:], uint64(req.Timestamp))
+	mac.Write(num[:])
+	field(req.Nonce)
+	binary.LittleEndian.PutUint32(num[:4], uint32(len(req.Features)))
+	mac.Write(num[:4])
+	for _, feature := range req.Features {
+		field(feature)
+	}
+	return mac.Sum(nil)
+}
@@ -0,0 +1,67 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"errors"
+	"testing"
+)
+
+//------------------------------------------------------------------------------
+//	Tests
+//------------------------------------------------------------------------------
+
+func TestHandshakeToken(t *testing.T) {
+	server := NewConnectorServer("127.0.0.1:0")
+	server.Auth = &ServerAuth{Tokens: []string{"good"}, Secret: []byte("secret")}
+	if err := server.Start(); err != nil {
+		t.Fatalf("start server failed: %v", err)
+	}
+	defer server.Shutdown()
+
+	for _, tc := range []struct {
+		name   string
+		header string
+		token  string
+		err    error
+	}{
+		{"token from auth", "good", "", nil},
+		{"matching token", "good", "good", nil},
+		{"mismatched token", "good", "bad", ErrHandshakeRejected},
+		{"missing bearer", "", "good", ErrUnauthorized},
+	} {
+		config := DefaultConnectorConfig(server.Address())
+		config.Auth = &ConnectorAuth{Token: tc.header, Secret: []byte("secret")}
+		config.Handshake = &HandshakeData{Service: "auth_test", Token: tc.token}
+		c := NewConnector(config)
+		err := c.Connect()
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+		}
+		if err == nil {
+			if peer := server.Connectors(); len(peer) == 0 {
+				t.Errorf("%s: connection not accepted", tc.name)
+			}
+			c.Disconnect()
+		}
+	}
+}
@@ -45,6 +45,8 @@ type (
 		cancel(work *Task)
 
 		completed(work *Task)
+
+		orders() []*OrderData
 	}
 
 	barrier struct {
@@ -80,7 +82,7 @@ type (
 // @param	params	要拿來排隊的物件，物件必須 embedded essence.OrderData 才能處理
 func MakeBarrier(params ...interface{}) interface{} {
 	if err := orderChecker(params); err != nil {
-		Error("MakeBarrier: ERR=%s", err.Error())
+		logger(barrierLog).Error("MakeBarrier: ERR=%s", err.Error())
 		return nil
 	}
 	length := len(params)
@@ -106,7 +108,7 @@ func MakeBarrier(params ...interface{}) interface{} {
 // @param	params		要拿來排隊的物件，物件必須 embedded essence.OrderData 才能處理
 func DelayBarrier(duration time.Duration, params ...interface{}) interface{} {
 	if err := orderChecker(params); err != nil {
-		Error("DelayBarrier: ERR=%s", err.Error())
+		logger(barrierLog).Error("DelayBarrier: ERR=%s", err.Error())
 		return nil
 	}
 	length := len(params)
@@ -196,7 +198,7 @@ func (o *OrderData) completeWork(work *Task) {
 
 func (o *OrderData) removeFirstWork(work *Task) {
 	if o.getFirstWork() != work {
-		Error("OrderData:removeFirstWork: diff work. NAME=%s", work.name)
+		logger(barrierLog).Error("OrderData:removeFirstWork: diff work. NAME=%s", work.name)
 		return
 	}
 	o.works.Pop()
@@ -213,7 +215,7 @@ func (o *OrderData) cancelFirstWork(work *Task) {
 
 func (o *OrderData) expireDelayed(work *Task) {
 	if o.delays.Contains(work) == false {
-		Error("OrderData:expireDelayed: not found. NAME=%s", work.name)
+		logger(barrierLog).Error("OrderData:expireDelayed: not found. NAME=%s", work.name)
 		return
 	}
 	o.delays.Remove(work)
@@ -245,6 +247,10 @@ func (b *barrier) completed(work *Task) {
 	b.data.completeWork(work)
 }
 
+func (b *barrier) orders() []*OrderData {
+	return []*OrderData{b.data}
+}
+
 //------------------------------------------------------------------------------
 
 func (m *multiBarrier) isClear(work *Task) bool {
@@ -278,6 +284,10 @@ func (m *multiBarrier) completed(work *Task) {
 	}
 }
 
+func (m *multiBarrier) orders() []*OrderData {
+	return m.datas
+}
+
 //------------------------------------------------------------------------------
 
 func (d *delayBarrier) isClear(work *Task) bool {
@@ -308,6 +318,10 @@ func (d *delayBarrier) completed(work *Task) {
 	d.data.completeWork(work)
 }
 
+func (d *delayBarrier) orders() []*OrderData {
+	return []*OrderData{d.data}
+}
+
 func (d *delayBarrier) onExpired() {
 	if d.expired == false {
 		d.expired = true
@@ -363,6 +377,10 @@ func (m *delayMultiBarrier) completed(work *Task) {
 	}
 }
 
+func (m *delayMultiBarrier) orders() []*OrderData {
+	return m.datas
+}
+
 func (m *delayMultiBarrier) onExpired() {
 	if m.expired == false {
 		m.expired = true
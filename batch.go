@@ -0,0 +1,138 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//	Constants
+//------------------------------------------------------------------------------
+
+const (
+	// FeatureBatch : 多個命令合併在同一個 websocket 訊息中送出
+	FeatureBatch = "batch"
+	// 每個命令前的長度欄位
+	batchLengthSize = 4
+)
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+// isBatch : 是否為合併的封包
+//
+//	cmd(commandBatchFlag) | length | [length(4) | Command.Bytes()]...
+func isBatch(frame []byte) bool {
+	return len(frame) >= 4 && binary.LittleEndian.Uint32(frame)&commandBatchFlag != 0
+}
+
+// appendBatch : 將多個封包合併後編碼在 dst 之後
+func appendBatch(dst []byte, frames []*frameBuffer) []byte {
+	start := len(dst)
+	dst = append(dst, make([]byte, commandHeaderSize)...)
+	var size [batchLengthSize]byte
+	for _, frame := range frames {
+		binary.LittleEndian.PutUint32(size[:], uint32(len(frame.data)))
+		dst = append(dst, size[:]...)
+		dst = append(dst, frame.data...)
+	}
+	binary.LittleEndian.PutUint32(dst[start:], commandBatchFlag)
+	binary.LittleEndian.PutUint32(dst[start+4:], uint32(len(dst)-start-commandHeaderSize))
+	return dst
+}
+
+// splitBatch : 將合併的封包拆回原本的封包，回傳的 slice 共用 batch 的記憶體
+func splitBatch(batch []byte) ([][]byte, error) {
+	if len(batch) < commandHeaderSize {
+		return nil, fmt.Errorf("invalid batch length %d", len(batch))
+	}
+	length := int(binary.LittleEndian.Uint32(batch[4:]))
+	data := batch[commandHeaderSize:]
+	if length != len(data) {
+		return nil, fmt.Errorf("batch length mismatch. LENGTH=%d, DATA=%d", length, len(data))
+	}
+	var frames [][]byte
+	for len(data) > 0 {
+		if len(data) < batchLengthSize {
+			return nil, fmt.Errorf("invalid batch entry")
+		}
+		size := int(binary.LittleEndian.Uint32(data))
+		data = data[batchLengthSize:]
+		if size > len(data) {
+			return nil, fmt.Errorf("batch entry overflow. SIZE=%d, REMAIN=%d", size, len(data))
+		}
+		frame := data[:size:size]
+		if isBatch(frame) || isChunk(frame) {
+			return nil, fmt.Errorf("nested batch entry")
+		}
+		frames = append(frames, frame)
+		data = data[size:]
+	}
+	return frames, nil
+}
+
+//------------------------------------------------------------------------------
+
+// collectBatch : 從送出佇列中收集可以一起送出的封包
+// @param	first	第一個封包
+// @param	size	合併後的長度上限
+// @param	delay	等待更多封包的時間，0 則只收集已在佇列中的
+// @param	link	此連線協商後的傳輸功能，見 prepare
+// @return	要合併的封包，以及放不下、留待下次送出的封包
+func (c *Connector) collectBatch(first *frameBuffer, size int, delay time.Duration, link linkFeatures) ([]*frameBuffer, *frameBuffer) {
+	frames := []*frameBuffer{first}
+	total := commandHeaderSize + batchLengthSize + len(first.data)
+	var timeout <-chan time.Time
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	for {
+		var next *frameBuffer
+		if timeout == nil {
+			select {
+			case next = <-c.message:
+			default:
+				return frames, nil
+			}
+		} else {
+			select {
+			case next = <-c.message:
+			case <-timeout:
+				return frames, nil
+			}
+		}
+		if !c.prepare(next, link) {
+			continue
+		}
+		if total+batchLengthSize+len(next.data) > size {
+			return frames, next
+		}
+		frames = append(frames, next)
+		total += batchLengthSize + len(next.data)
+	}
+}
@@ -0,0 +1,122 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+//------------------------------------------------------------------------------
+//	Constants
+//------------------------------------------------------------------------------
+
+const (
+	// 分段表頭長度: cmd(4) + length(4) + total(4)
+	chunkHeaderSize = commandHeaderSize + 4
+	// 支援分段的最低協定版本
+	chunkProtocolVersion uint32 = 2
+)
+
+//------------------------------------------------------------------------------
+//	Structure declare
+//------------------------------------------------------------------------------
+
+type (
+	// chunkReader : 將分段的封包組回原本的封包，同一個連線的分段必定連續送達
+	//
+	//	cmd(commandChunkFlag | 型別) | length(本段長度) | total(原封包長度) | data
+	chunkReader struct {
+		buffer []byte // 已收到的資料
+		total  int    // 原封包長度，0 表示沒有進行中的封包
+		max    int    // 原封包長度上限
+	}
+)
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+// isChunk : 是否為分段封包
+func isChunk(frame []byte) bool {
+	return len(frame) >= 4 && binary.LittleEndian.Uint32(frame)&commandChunkFlag != 0
+}
+
+// splitChunks : 將超過 size 的封包切成多個分段
+// @param	frame	Command.Bytes() 的結果
+// @param	size	每個分段 (包含表頭) 的長度上限
+func splitChunks(frame []byte, size int) [][]byte {
+	if len(frame) <= size {
+		return [][]byte{frame}
+	}
+	cmd := binary.LittleEndian.Uint32(frame)&^commandFlagMask | commandChunkFlag
+	piece := size - chunkHeaderSize
+	res := make([][]byte, 0, (len(frame)+piece-1)/piece)
+	for offset := 0; offset < len(frame); offset += piece {
+		end := offset + piece
+		if end > len(frame) {
+			end = len(frame)
+		}
+		chunk := make([]byte, chunkHeaderSize+end-offset)
+		binary.LittleEndian.PutUint32(chunk[0:], cmd)
+		binary.LittleEndian.PutUint32(chunk[4:], uint32(end-offset))
+		binary.LittleEndian.PutUint32(chunk[8:], uint32(len(frame)))
+		copy(chunk[chunkHeaderSize:], frame[offset:end])
+		res = append(res, chunk)
+	}
+	return res
+}
+
+//------------------------------------------------------------------------------
+
+// feed : 加入一個分段
+// @return	組合完成的封包，尚未完成時為 nil
+func (r *chunkReader) feed(chunk []byte) ([]byte, error) {
+	if len(chunk) < chunkHeaderSize {
+		return nil, fmt.Errorf("invalid chunk length %d", len(chunk))
+	}
+	length := int(binary.LittleEndian.Uint32(chunk[4:]))
+	total := int(binary.LittleEndian.Uint32(chunk[8:]))
+	data := chunk[chunkHeaderSize:]
+	if length != len(data) {
+		return nil, fmt.Errorf("chunk length mismatch. LENGTH=%d, DATA=%d", length, len(data))
+	}
+	if r.total == 0 {
+		if total <= 0 || total > r.max {
+			return nil, fmt.Errorf("command too large. SIZE=%d, MAX=%d", total, r.max)
+		}
+		r.total = total
+		r.buffer = make([]byte, 0, total)
+	} else if total != r.total {
+		return nil, fmt.Errorf("chunk total mismatch. TOTAL=%d, EXPECT=%d", total, r.total)
+	}
+	if len(r.buffer)+len(data) > r.total {
+		return nil, fmt.Errorf("chunk overflow. TOTAL=%d", r.total)
+	}
+	r.buffer = append(r.buffer, data...)
+	if len(r.buffer) < r.total {
+		return nil, nil
+	}
+	frame := r.buffer
+	r.buffer, r.total = nil, 0
+	return frame, nil
+}
@@ -22,22 +22,78 @@ package agency
 //------------------------------------------------------------------------------
 
 import (
+	"context"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"reflect"
+	"sync"
 
 	"github.com/gogo/protobuf/proto"
 )
 
+//------------------------------------------------------------------------------
+//	Constants
+//------------------------------------------------------------------------------
+
+const (
+	// 封包表頭長度: cmd(4) + length(4)
+	commandHeaderSize = 8
+	// cmd 的高 4 個位元保留為旗標，其餘為命令型別
+	commandFlagMask uint32 = 0xF0000000
+	// 表頭後帶有 trace 資料
+	commandTraceFlag uint32 = 1 << 31
+	// 分段封包，見 chunkReader
+	commandChunkFlag uint32 = 1 << 30
+	// body 以 deflate 壓縮
+	commandCompressFlag uint32 = 1 << 29
+	// 多個封包合併，見 appendBatch
+	commandBatchFlag uint32 = 1 << 28
+
+	// frameBuffer 的初始容量
+	frameBufferSize = 512
+	// 超過此容量的 frameBuffer 不放回 pool，避免佔用過多記憶體
+	frameBufferMaxSize = 64 << 10
+)
+
+//------------------------------------------------------------------------------
+//	Variables
+//------------------------------------------------------------------------------
+
+var (
+	// 送出用的封包 buffer
+	framePool = sync.Pool{
+		New: func() interface{} {
+			return &frameBuffer{data: make([]byte, 0, frameBufferSize)}
+		},
+	}
+)
+
 //------------------------------------------------------------------------------
 //	Structure declare
 //------------------------------------------------------------------------------
 
+type (
+	// frameBuffer : 編碼後等待送出的封包，送出後放回 framePool
+	frameBuffer struct {
+		data []byte
+	}
+
+	// sizedMarshaler : 產生的 protobuf 型別皆有實作，可以直接 marshal 到指定的 slice 之後
+	sizedMarshaler interface {
+		XXX_Size() int
+		XXX_Marshal(b []byte, deterministic bool) ([]byte, error)
+	}
+)
+
 // Command : 通訊協定封包
+//
+//	cmd | length | [trace(25)] | body
 type Command struct {
-	cmd    uint32 // 命令型別
-	length uint32 // body 長度
-	body   []byte // 命令資料
+	cmd    uint32        // 命令型別 (含旗標)
+	length uint32        // body 長度
+	body   []byte        // 命令資料
+	trace  *TraceContext // 追蹤資訊，cmd 有 commandTraceFlag 時才有
 }
 
 //------------------------------------------------------------------------------
@@ -49,10 +105,27 @@ type Command struct {
 // @return	Command object & error
 func CreateCommand(data []byte) (*Command, error) {
 	length := len(data)
-	if length < 8 {
+	if length < commandHeaderSize {
 		return nil, errors.New("invalid length")
 	}
-	return &Command{binary.LittleEndian.Uint32(data[0:4]), binary.LittleEndian.Uint32(data[4:8]), data[8:]}, nil
+	cmd := &Command{
+		cmd:    binary.LittleEndian.Uint32(data[0:4]),
+		length: binary.LittleEndian.Uint32(data[4:8]),
+	}
+	data = data[commandHeaderSize:]
+	if cmd.cmd&commandTraceFlag != 0 {
+		if len(data) < traceWireSize {
+			return nil, errors.New("invalid trace length")
+		}
+		tc := decodeTrace(data)
+		cmd.trace = &tc
+		data = data[traceWireSize:]
+	}
+	if int(cmd.length) != len(data) {
+		return nil, fmt.Errorf("length mismatch. LENGTH=%d, DATA=%d", cmd.length, len(data))
+	}
+	cmd.body = data
+	return cmd, nil
 }
 
 // NewCommand : 以命令編號 + proto.Message 類的資料來建立一個 Command 物件
@@ -60,7 +133,7 @@ func NewCommand(cmd interface{}, pb proto.Message) *Command {
 	// try to marshal given message.
 	body, err := proto.Marshal(pb)
 	if err != nil {
-		Error("Player:Send: invalid data. CMD=%v, ERR=%s", reflect.ValueOf(cmd), err.Error())
+		logger(connectorLog).Error("Player:Send: invalid data. CMD=%v, ERR=%s", reflect.ValueOf(cmd), err.Error())
 		return nil
 	}
 	// check the command type
@@ -72,15 +145,38 @@ func NewCommand(cmd interface{}, pb proto.Message) *Command {
 	case reflect.Uint, reflect.Uint32, reflect.Uint64:
 		cmdType = uint32(val.Uint())
 	default:
-		Error("Player:Send: invalid command type. CMD=%v, KIND=%s", val, val.Kind().String())
+		logger(connectorLog).Error("Player:Send: invalid command type. CMD=%v, KIND=%s", val, val.Kind().String())
 		return nil
 	}
-	return &Command{cmdType, uint32(len(body)), body}
+	return &Command{cmd: cmdType, length: uint32(len(body)), body: body}
 }
 
 // Type : Retrieves the command type.
 func (c *Command) Type() uint32 {
-	return c.cmd
+	return c.cmd &^ commandFlagMask
+}
+
+// Trace : 取得封包帶有的追蹤資訊
+func (c *Command) Trace() (TraceContext, bool) {
+	if c.trace == nil {
+		return TraceContext{}, false
+	}
+	return *c.trace, true
+}
+
+// Context : 帶有封包 trace 的 context，命令處理中傳給 SendContext、SendWorkContext 以延續 trace
+func (c *Command) Context() context.Context {
+	ctx := context.Background()
+	if c.trace != nil {
+		ctx = WithTrace(ctx, *c.trace)
+	}
+	return ctx
+}
+
+// SetTrace : 設定封包的追蹤資訊，送出時會寫在表頭之後
+func (c *Command) SetTrace(tc TraceContext) {
+	c.trace = &tc
+	c.cmd |= commandTraceFlag
 }
 
 // Length : Retrieves the command length -> body
@@ -94,10 +190,91 @@ func (c *Command) Data() []byte {
 }
 
 // Bytes : 將 Command 轉化為可以送出的 byte array 資料
-func (c *Command) Bytes() (result []byte) {
-	result = make([]byte, 8+c.length)
-	binary.LittleEndian.PutUint32(result[0:], c.cmd)
-	binary.LittleEndian.PutUint32(result[4:], c.length)
-	copy(result[8:], c.body)
-	return
+func (c *Command) Bytes() []byte {
+	size := commandHeaderSize + int(c.length)
+	var trace TraceContext
+	if c.trace != nil {
+		size += traceWireSize
+		trace = *c.trace
+	}
+	result, _ := appendCommand(make([]byte, 0, size), c.cmd, c.body, nil, trace)
+	return result
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+// appendCommand : 將封包編碼在 dst 之後，pb 不為 nil 時直接 marshal 到 dst 中，不另外配置 body
+// @param	body	命令資料，pb 不為 nil 時忽略
+// @param	trace	追蹤資訊，IsValid() 為 false 則不帶
+func appendCommand(dst []byte, cmd uint32, body []byte, pb proto.Message, trace TraceContext) ([]byte, error) {
+	start := len(dst)
+	cmd &^= commandTraceFlag
+	dst = append(dst, make([]byte, commandHeaderSize)...)
+	if trace.IsValid() {
+		cmd |= commandTraceFlag
+		dst = append(dst, make([]byte, traceWireSize)...)
+		trace.encode(dst[start+commandHeaderSize:])
+	}
+	offset := len(dst)
+	if pb == nil {
+		dst = append(dst, body...)
+	} else if m, ok := pb.(sizedMarshaler); ok {
+		size := m.XXX_Size()
+		if cap(dst)-len(dst) < size {
+			grown := make([]byte, len(dst), len(dst)+size)
+			copy(grown, dst)
+			dst = grown
+		}
+		var err error
+		if dst, err = m.XXX_Marshal(dst, false); err != nil {
+			return dst[:start], err
+		}
+	} else {
+		data, err := proto.Marshal(pb)
+		if err != nil {
+			return dst[:start], err
+		}
+		dst = append(dst, data...)
+	}
+	binary.LittleEndian.PutUint32(dst[start:], cmd)
+	binary.LittleEndian.PutUint32(dst[start+4:], uint32(len(dst)-offset))
+	return dst, nil
+}
+
+// frameType : 已編碼封包的命令型別 (不含旗標)
+func frameType(frame []byte) uint32 {
+	return binary.LittleEndian.Uint32(frame) &^ commandFlagMask
+}
+
+// stripTrace : 移除已編碼封包中的 trace 資料
+func stripTrace(frame []byte) []byte {
+	n := copy(frame[commandHeaderSize:], frame[commandHeaderSize+traceWireSize:])
+	binary.LittleEndian.PutUint32(frame, binary.LittleEndian.Uint32(frame)&^commandTraceFlag)
+	return frame[:commandHeaderSize+n]
+}
+
+// frameBodyOffset : 已編碼封包的 body 位置
+func frameBodyOffset(frame []byte) int {
+	if binary.LittleEndian.Uint32(frame)&commandTraceFlag != 0 {
+		return commandHeaderSize + traceWireSize
+	}
+	return commandHeaderSize
+}
+
+//------------------------------------------------------------------------------
+
+// getFrame : 從 framePool 取得 frameBuffer
+func getFrame() *frameBuffer {
+	return framePool.Get().(*frameBuffer)
+}
+
+// putFrame : 將 frameBuffer 放回 framePool，之後不可再使用
+func putFrame(f *frameBuffer) {
+	if cap(f.data) > frameBufferMaxSize {
+		return
+	}
+	f.data = f.data[:0]
+	framePool.Put(f)
 }
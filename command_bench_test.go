@@ -0,0 +1,133 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gorilla/websocket"
+)
+
+//------------------------------------------------------------------------------
+//	Benchmarks
+//------------------------------------------------------------------------------
+
+// BenchmarkProtoMarshalCopy : 對照組，先 proto.Marshal 再複製到另外配置的封包
+func BenchmarkProtoMarshalCopy(b *testing.B) {
+	pb := newBenchMessage()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		body, err := proto.Marshal(pb)
+		if err != nil {
+			b.Fatal(err)
+		}
+		frame := make([]byte, commandHeaderSize+len(body))
+		binary.LittleEndian.PutUint32(frame[0:], 7)
+		binary.LittleEndian.PutUint32(frame[4:], uint32(len(body)))
+		copy(frame[commandHeaderSize:], body)
+	}
+}
+
+func BenchmarkCommandBytes(b *testing.B) {
+	cmd := NewCommand(uint32(7), newBenchMessage())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		cmd.Bytes()
+	}
+}
+
+// BenchmarkConnectorEnqueue : 只量測編碼到放入佇列，不含實際寫出
+func BenchmarkConnectorEnqueue(b *testing.B) {
+	benchEnqueue(b, context.Background(), false)
+}
+
+// BenchmarkConnectorEnqueueTraced : ctx 帶有 trace 且對方接受 trace，沒有設定 Tracer
+func BenchmarkConnectorEnqueueTraced(b *testing.B) {
+	benchEnqueue(b, WithTrace(context.Background(), NewTraceContext()), true)
+}
+
+func BenchmarkConnectorSend(b *testing.B) {
+	level := logLevel.Value()
+	SetLogLevel("ERROR")
+	defer logLevel.Exchange(level)
+
+	server := NewConnectorServer("127.0.0.1:0")
+	server.CommandHandler = func(c *Connector, cmd *Command) {}
+	if err := server.Start(); err != nil {
+		b.Fatal(err)
+	}
+	defer server.Shutdown()
+	config := DefaultConnectorConfig(server.Address())
+	config.Handshake = &HandshakeData{Service: "bench"}
+	c := NewConnector(config)
+	if err := c.Connect(); err != nil {
+		b.Fatal(err)
+	}
+	defer c.Disconnect()
+
+	pb := newBenchMessage()
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := c.SendContext(ctx, uint32(7), pb); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+// benchEnqueue : 以未實際連線的 Connector 量測 SendContext，每次送出後自行取出佇列
+func benchEnqueue(b *testing.B, ctx context.Context, traced bool) {
+	c := NewConnector(DefaultConnectorConfig("127.0.0.1:0"))
+	c.conn = &websocket.Conn{}
+	c.link.traced = traced
+	pb := newBenchMessage()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := c.SendContext(ctx, uint32(7), pb); err != nil {
+			b.Fatal(err)
+		}
+		putFrame(<-c.message)
+	}
+}
+
+// newBenchMessage : 一般大小的命令資料
+func newBenchMessage() *HandshakeData {
+	return &HandshakeData{
+		Service:     "bench",
+		Instance:    "bench-01",
+		Version:     ProtocolVersion,
+		MinVersion:  MinProtocolVersion,
+		CommandSets: []string{"lobby", "game"},
+		Token:       "0123456789abcdef0123456789abcdef",
+		Timestamp:   1600000000,
+		Nonce:       "nonce",
+	}
+}
@@ -0,0 +1,53 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"testing"
+)
+
+//------------------------------------------------------------------------------
+//	Tests
+//------------------------------------------------------------------------------
+
+func TestCreateCommand(t *testing.T) {
+	tc := NewTraceContext()
+	frame, _ := appendCommand(nil, 7, []byte("hello"), nil, tc)
+	cmd, err := CreateCommand(frame)
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if trace, ok := cmd.Trace(); cmd.Type() != 7 || string(cmd.Data()) != "hello" || !ok || trace != tc {
+		t.Fatalf("unexpected command. CMD=%d, DATA=%q", cmd.Type(), cmd.Data())
+	}
+
+	for name, data := range map[string][]byte{
+		"truncated": frame[:len(frame)-1],
+		"padded":    append(append([]byte(nil), frame...), 0),
+		"header":    frame[:commandHeaderSize-1],
+		"trace":     frame[:commandHeaderSize+traceWireSize-1],
+	} {
+		if _, err := CreateCommand(data); err == nil {
+			t.Errorf("%s frame should be rejected", name)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+)
+
+//------------------------------------------------------------------------------
+//	Constants
+//------------------------------------------------------------------------------
+
+const (
+	// FeatureDeflate : 超過 CompressThreshold 的命令以 deflate 壓縮 body
+	FeatureDeflate = "deflate"
+)
+
+//------------------------------------------------------------------------------
+//	Variables
+//------------------------------------------------------------------------------
+
+var (
+	// 壓縮結果的暫存
+	compressBuffers = sync.Pool{
+		New: func() interface{} {
+			return new(bytes.Buffer)
+		},
+	}
+	// 壓縮用的 flate.Writer
+	deflaters = sync.Pool{
+		New: func() interface{} {
+			w, _ := flate.NewWriter(nil, flate.BestSpeed)
+			return w
+		},
+	}
+)
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+// compressFrame : 壓縮已編碼封包的 body，結果寫回原本的記憶體，壓縮後沒有變小則維持原狀
+// @return	壓縮後的封包，以及是否已壓縮
+func compressFrame(frame []byte) ([]byte, bool) {
+	offset := frameBodyOffset(frame)
+	buffer := compressBuffers.Get().(*bytes.Buffer)
+	defer compressBuffers.Put(buffer)
+	buffer.Reset()
+	w := deflaters.Get().(*flate.Writer)
+	w.Reset(buffer)
+	w.Write(frame[offset:])
+	w.Close()
+	deflaters.Put(w)
+	if buffer.Len() >= len(frame)-offset {
+		return frame, false
+	}
+	frame = frame[:offset+copy(frame[offset:], buffer.Bytes())]
+	binary.LittleEndian.PutUint32(frame[0:], binary.LittleEndian.Uint32(frame)|commandCompressFlag)
+	binary.LittleEndian.PutUint32(frame[4:], uint32(buffer.Len()))
+	return frame, true
+}
+
+// inflateFrame : 還原已編碼封包中壓縮的 body，沿用原本的記憶體
+// @param	max	還原後的長度上限
+func inflateFrame(frame []byte, max int) ([]byte, error) {
+	offset := frameBodyOffset(frame)
+	body, err := inflate(frame[offset:], max)
+	if err != nil {
+		return frame, err
+	}
+	frame = append(frame[:offset], body...)
+	binary.LittleEndian.PutUint32(frame[0:], binary.LittleEndian.Uint32(frame)&^commandCompressFlag)
+	binary.LittleEndian.PutUint32(frame[4:], uint32(len(body)))
+	return frame, nil
+}
+
+// inflate : 解壓縮
+// @param	max	還原後的長度上限
+func inflate(data []byte, max int) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(data))
+	defer r.Close()
+	// 多讀 1 byte 來判斷是否超過上限
+	body, err := ioutil.ReadAll(io.LimitReader(r, int64(max)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > max {
+		return nil, fmt.Errorf("decompressed command too large. MAX=%d", max)
+	}
+	return body, nil
+}
+
+// decompress : 還原壓縮的 body
+// @param	max	還原後的長度上限
+func (c *Command) decompress(max int) error {
+	if c.cmd&commandCompressFlag == 0 {
+		return nil
+	}
+	body, err := inflate(c.body, max)
+	if err != nil {
+		return err
+	}
+	c.body = body
+	c.length = uint32(len(body))
+	c.cmd &^= commandCompressFlag
+	return nil
+}
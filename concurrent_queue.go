@@ -120,3 +120,15 @@ func (q *ConcurrentQueue) Peek() interface{} {
 	}
 	return nil
 }
+
+// ToSlice Convert all queue items to slice, from front to back.
+func (q *ConcurrentQueue) ToSlice() []interface{} {
+	q.Lock()
+	defer q.Unlock()
+
+	res := make([]interface{}, 0, q.count)
+	for n := q.head; n != nil; n = n.next {
+		res = append(res, n.data)
+	}
+	return res
+}
@@ -22,11 +22,17 @@ package agency
 //------------------------------------------------------------------------------
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/binary"
 	"errors"
-	"flag"
-	"net/url"
+	"fmt"
+	"net"
+	"net/http"
 	"reflect"
+	"sync"
+	"time"
 
 	"github.com/gogo/protobuf/proto"
 	"github.com/gorilla/websocket"
@@ -41,6 +47,21 @@ const (
 	maxMessageSize int64 = 5120
 )
 
+//------------------------------------------------------------------------------
+//	Variables
+//------------------------------------------------------------------------------
+
+var (
+	// ErrNotConnected : 尚未連線，或重新連線中且沒有設定 HoldOnReconnect
+	ErrNotConnected = errors.New("not connected")
+	// ErrQueueFull : 送出佇列已滿
+	ErrQueueFull = errors.New("send queue full")
+	// ErrClosed : 已經 Disconnect
+	ErrClosed = errors.New("connector closed")
+	// ErrMessageTooLarge : 命令超過 MaxCommandSize，或對方不支援分段而超過 ChunkSize
+	ErrMessageTooLarge = errors.New("message too large")
+)
+
 //------------------------------------------------------------------------------
 //	Structure declare
 //------------------------------------------------------------------------------
@@ -51,93 +72,379 @@ type (
 
 	// Connector : 連線往 AgencyService的物件
 	Connector struct {
-		// websocket 連線
+		// websocket 連線，nil 表示未連線
 		conn *websocket.Conn
-		// 寫出資料的 byte slice channel
-		message chan []byte
-		// 結束旗標
+		// 送出佇列，跨越重新連線
+		message chan *frameBuffer
+		// 目前連線的結束旗標
 		closeSignal chan struct{}
+		// 停止重新連線的旗標，Disconnect 時關閉
+		stopSignal chan struct{}
+		// 是否已經 Disconnect
+		closed bool
 		// 位置
 		address string
+		// 設定
+		config ConnectorConfig
+		// 連線統計
+		stats *connectorStats
+		// 對方的 handshake，ConnectorServer 接受的連線才有
+		peer *HandshakeData
+		// 協商後的協定版本，0 表示沒有進行 handshake
+		version uint32
+		// 協商後雙方皆支援的命令集
+		commandSets []string
+		// 協商後雙方皆啟用的傳輸功能
+		features []string
+		// 目前連線協商後的傳輸功能
+		link linkFeatures
+		// 斷線時呼叫，ConnectorServer 用來移除連線
+		onClose func()
+		// ConnectorServer 以 TLS 接受的連線資訊
+		tlsState *tls.ConnectionState
+		// 沒有 handshake 的舊版 client 送出的第一個封包，開始讀取時先處理
+		first []byte
+		// 保護連線狀態
+		lock sync.Mutex
 		//
 		CommandHandler OnCommandMethod
 	}
+
+	// linkFeatures : 連線協商後的傳輸功能，重新連線後送出保留的封包時依此調整格式
+	linkFeatures struct {
+		chunked bool // 對方支援分段
+		batched bool // 合併送出
+		traced  bool // 對方接受表頭後的 trace 資料
+		deflate bool // 壓縮送出
+	}
 )
 
 //------------------------------------------------------------------------------
 //	Public Methods
 //------------------------------------------------------------------------------
 
-// NewConnector : 以 address:port 來建立一個 Connector 物件
-func NewConnector(address string) *Connector {
+// NewConnector : Connector object creator.
+// @param	config	連線設定，ex: DefaultConnectorConfig("127.0.0.1:8600")
+func NewConnector(config ConnectorConfig) *Connector {
 	connector := &Connector{
 		conn:    nil,
-		address: address,
+		address: config.URL,
+		message: make(chan *frameBuffer, config.sendQueueSize()),
+		config:  config,
+		stats:   newConnectorStats(),
 	}
 	return connector
 }
 
-// Connect : 開始連線
-func (c *Connector) Connect() bool {
-	if c.conn != nil {
-		Error("Connector:Connect: already connect.")
-		return false
+// Connect : 開始連線，有設定 Handshake 時會等待 AgencyService 接受後才返回。
+// 第一次連線失敗時不會重新連線
+// @return	error, handshake 被拒絕時為 ErrHandshakeRejected
+func (c *Connector) Connect() error {
+	c.lock.Lock()
+	if c.conn != nil || c.stopSignal != nil {
+		c.lock.Unlock()
+		logger(connectorLog).Error("Connector:Connect: already connect.")
+		return errors.New("already connect")
+	}
+	stop := make(chan struct{})
+	c.stopSignal = stop
+	c.closed = false
+	c.lock.Unlock()
+
+	conn, ack, err := c.dial()
+	if err == nil && !c.attach(conn, ack, stop) {
+		conn.Close()
+		err = errors.New("disconnected while connecting")
 	}
-	var addr = flag.String("addr", c.address, "AgencyService address")
-	u := url.URL{Scheme: "ws", Host: *addr, Path: "/"}
-	var err error
-	c.conn, _, err = websocket.DefaultDialer.Dial(u.String(), nil)
 	if err != nil {
-		Error("Connector:Connect: cannot connect. ERR=%s", err.Error())
-		return false
+		c.lock.Lock()
+		if c.stopSignal == stop {
+			c.stopSignal = nil
+		}
+		c.lock.Unlock()
+		return err
 	}
-	c.conn.SetReadLimit(maxMessageSize)
-	c.closeSignal = make(chan struct{})
-	c.message = make(chan []byte)
-	go c.readData()
-	go c.writeData()
-	return true
+	return nil
 }
 
-// Disconnect : 斷線
+// Disconnect : 斷線，並停止重新連線
 func (c *Connector) Disconnect() {
-	if c.conn == nil {
-		Error("Connector:Disconnect: not connect.")
+	c.lock.Lock()
+	conn := c.conn
+	stop := c.stopSignal
+	c.stopSignal = nil
+	c.closed = true
+	c.lock.Unlock()
+	if conn == nil && stop == nil {
+		logger(connectorLog).Error("Connector:Disconnect: not connect.")
 		return
 	}
-	err := c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
-	if err != nil {
-		Error("Connector:Disconnect: error occur. ERR=%s", err.Error())
+	if stop != nil {
+		close(stop)
+	}
+	if conn == nil {
+		// 重新連線中
+		c.discard()
 		return
 	}
-	close(c.closeSignal)
-	close(c.message)
+	err := conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	if err != nil {
+		logger(connectorLog).Error("Connector:Disconnect: error occur. ERR=%s", err.Error())
+	}
+	c.drop(conn)
 }
 
-// SendCommand : 送出命令
-func (c *Connector) SendCommand(cmd uint32, body []byte) {
-	if c.conn == nil {
-		Error("Connector:SendCommand: not connect.")
-		return
-	}
-	length := uint32(len(body))
-	data := make([]byte, 8+length)
-	binary.LittleEndian.PutUint32(data[0:], cmd)
-	binary.LittleEndian.PutUint32(data[4:], length)
-	copy(data[8:], body)
-	c.message <- data
+// SendCommand : 送出命令，放入送出佇列後立即返回
+// @return	error, ErrNotConnected、ErrQueueFull 或 ErrClosed
+func (c *Connector) SendCommand(cmd uint32, body []byte) error {
+	return c.enqueue(nil, cmd, body, nil)
+}
+
+// SendCommandContext : 送出命令，佇列已滿時等待到 ctx 結束，並延續 ctx 中的 trace (見 WithTrace)
+// @return	error, ErrNotConnected、ErrClosed 或 ctx.Err()
+func (c *Connector) SendCommandContext(ctx context.Context, cmd uint32, body []byte) error {
+	return c.enqueue(ctx, cmd, body, nil)
 }
 
 // Send : 送出命令給 AgencyServer
 // @param	cmd		通訊命令 <- 必須是 uint32 or int32
 // @param	pb		通訊協定內容，為 protobuf 中的 Message 型別
 func (c *Connector) Send(cmd interface{}, pb proto.Message) error {
-	// try to marshal given message.
-	body, err := proto.Marshal(pb)
+	return c.send(nil, cmd, pb)
+}
+
+// SendContext : 同 Send，佇列已滿時等待到 ctx 結束，並延續 ctx 中的 trace (見 WithTrace)
+func (c *Connector) SendContext(ctx context.Context, cmd interface{}, pb proto.Message) error {
+	return c.send(ctx, cmd, pb)
+}
+
+// Peer : 對方的 handshake 資料，只有 ConnectorServer 接受的連線才有
+func (c *Connector) Peer() *HandshakeData {
+	return c.peer
+}
+
+// Version : 協商後的協定版本，0 表示沒有進行 handshake
+func (c *Connector) Version() uint32 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.version
+}
+
+// CommandSets : 協商後雙方皆支援的命令集
+func (c *Connector) CommandSets() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.commandSets
+}
+
+// Config : 取得連線設定
+func (c *Connector) Config() ConnectorConfig {
+	return c.config
+}
+
+// Features : 協商後雙方皆啟用的傳輸功能，ex: FeatureDeflate
+func (c *Connector) Features() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.features
+}
+
+// PeerCertificates : ConnectorServer 以 mTLS 接受的連線，對方的憑證
+func (c *Connector) PeerCertificates() []*x509.Certificate {
+	if c.tlsState == nil {
+		return nil
+	}
+	return c.tlsState.PeerCertificates
+}
+
+// GetStats : 取得連線統計
+func (c *Connector) GetStats() ConnectorStats {
+	res := c.stats.snapshot()
+	res.Queued = len(c.message)
+	return res
+}
+
+// OnCommand : 接收訊息
+func (c *Connector) OnCommand(cmd *Command) {
+	// 沒有設定 Tracer 時不建立 span，handler 直接延續對方的 trace
+	if tracing() {
+		var parent *TraceContext
+		if tc, ok := cmd.Trace(); ok {
+			parent = &tc
+		}
+		span := startSpan(SpanCommandReceive, parent, "cmd", cmd.Type(), "len", cmd.Length())
+		defer span.End(nil)
+		// 對方有帶 trace 時，handler 透過 cmd.Context() 延續此 trace
+		if tc := span.Context(); parent != nil && tc.IsValid() {
+			cmd.trace = &tc
+		}
+	}
+	if cmd.trace != nil {
+		logger(connectorLog).InfoKV("Connector:OnCommand", traceFields([]interface{}{"cmd", cmd.Type(), "len", cmd.Length()}, *cmd.trace)...)
+	} else {
+		logger(connectorLog).InfoKV("Connector:OnCommand", "cmd", cmd.Type(), "len", cmd.Length())
+	}
+	if c.CommandHandler == nil {
+		logger(connectorLog).Warn("Connector:OnCommand: no command handler. CMD=%d", cmd.Type())
+		return
+	}
+	c.CommandHandler(cmd)
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+// dial : 依設定建立連線並完成 handshake
+func (c *Connector) dial() (*websocket.Conn, *HandshakeAckData, error) {
+	u, err := c.config.url()
 	if err != nil {
-		Error("Connector:Send: invalid data. CMD=%v, ERR=%s", reflect.ValueOf(cmd), err.Error())
-		return err
+		logger(connectorLog).Error("Connector:dial: invalid url. URL=%s, ERR=%s", c.config.URL, err.Error())
+		return nil, nil, err
+	}
+	dialer := *websocket.DefaultDialer
+	dialer.HandshakeTimeout = c.config.dialTimeout()
+	dialer.WriteBufferSize = c.config.WriteBufferSize
+	dialer.TLSClientConfig = c.config.TLSConfig
+	header := http.Header{}
+	for k, v := range c.config.Header {
+		header[k] = append([]string(nil), v...)
+	}
+	handshake := c.config.Handshake
+	if auth := c.config.Auth; auth != nil {
+		for k, v := range auth.header() {
+			header[k] = v
+		}
+		if len(auth.Secret) > 0 && handshake == nil {
+			// 簽章必須透過 handshake 送出
+			handshake = &HandshakeData{Service: GetAppName()}
+		}
+	}
+	conn, resp, err := dialer.Dial(u.String(), header)
+	if err != nil {
+		if resp != nil && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden) {
+			err = fmt.Errorf("%w: %s", ErrUnauthorized, resp.Status)
+		}
+		logger(connectorLog).Error("Connector:dial: cannot connect. URL=%s, ERR=%s", u.String(), err.Error())
+		return nil, nil, err
+	}
+	conn.SetReadLimit(c.config.readLimit())
+	if handshake == nil {
+		return conn, nil, nil
+	}
+	ack, err := c.handshake(conn, handshake)
+	if err != nil {
+		logger(connectorLog).Error("Connector:dial: handshake failed. ERR=%s", err.Error())
+		conn.Close()
+		return nil, nil, err
+	}
+	logger(connectorLog).Info("Connector:dial: handshake accepted. VERSION=%d, SETS=%v", ack.Version, ack.CommandSets)
+	return conn, ack, nil
+}
+
+// attach : 使用新的連線並開始收送
+// @param	ack		handshake 的回應，nil 表示沒有進行 handshake
+// @param	stop	連線期間的 stopSignal，已經 Disconnect 時不使用此連線，nil 則不檢查
+// @return	是否使用此連線
+func (c *Connector) attach(conn *websocket.Conn, ack *HandshakeAckData, stop chan struct{}) bool {
+	c.lock.Lock()
+	if stop != nil && c.stopSignal != stop {
+		c.lock.Unlock()
+		return false
+	}
+	if ack != nil {
+		c.version = ack.Version
+		c.commandSets = ack.CommandSets
+		c.features = ack.Features
+	}
+	link := linkFeatures{chunked: c.version >= chunkProtocolVersion}
+	for _, feature := range c.features {
+		switch feature {
+		case FeatureTrace:
+			link.traced = true
+		case FeatureDeflate:
+			link.deflate = c.config.CompressThreshold > 0
+		case FeatureBatch:
+			link.batched = c.config.BatchSize > 0
+		}
+	}
+	c.link = link
+	c.conn = conn
+	c.closeSignal = make(chan struct{})
+	closeSignal := c.closeSignal
+	first := c.first
+	c.first = nil
+	c.lock.Unlock()
+
+	c.stats.connected()
+	go c.readData(conn, first)
+	go c.writeData(conn, closeSignal, link)
+	return true
+}
+
+// drop : 關閉指定的連線，重複呼叫或已經換成新的連線時無效。
+// 不是 Disconnect 造成的斷線，且有啟用重新連線時，開始重新連線
+func (c *Connector) drop(conn *websocket.Conn) {
+	c.lock.Lock()
+	if c.conn != conn {
+		c.lock.Unlock()
+		return
+	}
+	c.conn = nil
+	close(c.closeSignal)
+	stop := c.stopSignal
+	c.lock.Unlock()
+
+	conn.Close()
+	c.stats.disconnected()
+	if c.onClose != nil {
+		c.onClose()
+	}
+	if stop != nil && c.config.Reconnect.Enabled {
+		go c.reconnect(stop)
+		if c.config.HoldOnReconnect {
+			return
+		}
+	}
+	c.discard()
+}
+
+// reconnect : 依 ReconnectPolicy 重新連線，直到成功、放棄或 Disconnect
+func (c *Connector) reconnect(stop chan struct{}) {
+	policy := &c.config.Reconnect
+	for attempt := 1; ; attempt++ {
+		if policy.MaxRetries > 0 && attempt > policy.MaxRetries {
+			logger(connectorLog).Error("Connector:reconnect: give up. URL=%s, RETRIES=%d", c.config.URL, policy.MaxRetries)
+			c.lock.Lock()
+			if c.stopSignal == stop {
+				c.stopSignal = nil
+			}
+			c.lock.Unlock()
+			c.discard()
+			return
+		}
+		delay := policy.delay(attempt)
+		logger(connectorLog).Warn("Connector:reconnect: retry later. URL=%s, ATTEMPT=%d, DELAY=%s", c.config.URL, attempt, delay)
+		select {
+		case <-stop:
+			return
+		case <-time.After(delay):
+		}
+		conn, ack, err := c.dial()
+		if err != nil {
+			continue
+		}
+		if !c.attach(conn, ack, stop) {
+			conn.Close()
+			return
+		}
+		logger(connectorLog).Notice("Connector:reconnect: reconnected. URL=%s, ATTEMPT=%d", c.config.URL, attempt)
+		return
 	}
+}
+
+// send : 檢查命令型別後放入送出佇列
+func (c *Connector) send(ctx context.Context, cmd interface{}, pb proto.Message) error {
 	// check the command type
 	v := reflect.ValueOf(cmd)
 	var cmdType uint32
@@ -147,60 +454,370 @@ func (c *Connector) Send(cmd interface{}, pb proto.Message) error {
 	case reflect.Uint, reflect.Uint32, reflect.Uint64:
 		cmdType = uint32(v.Uint())
 	default:
-		Error("Connector:Send: invalid command type. CMD=%v, KIND=%s", v, v.Kind().String())
+		logger(connectorLog).Error("Connector:Send: invalid command type. CMD=%v, KIND=%s", v, v.Kind().String())
 		return errors.New("invalid cmd type")
 	}
-	// call the old school send method.
-	c.SendCommand(cmdType, body)
-	return nil
+	// marshal 在 enqueue 中直接寫入送出的 buffer
+	return c.enqueue(ctx, cmdType, nil, pb)
 }
 
-// OnCommand : 接收訊息
-func (c *Connector) OnCommand(cmd *Command) {
-	Info("Connector:OnCommand: CMD=%02d, LEN=%d", cmd.Type(), cmd.Length())
-	c.CommandHandler(cmd)
+// enqueue : 將命令編碼到 framePool 的 buffer 後放入送出佇列
+// @param	ctx	nil 則佇列已滿時直接回傳 ErrQueueFull
+// @param	pb	不為 nil 時 marshal 為 body，忽略 body
+func (c *Connector) enqueue(ctx context.Context, cmd uint32, body []byte, pb proto.Message) (err error) {
+	c.lock.Lock()
+	connected := c.conn != nil
+	reconnecting := !connected && c.stopSignal != nil
+	closed := c.closed
+	closeSignal, stop := c.closeSignal, c.stopSignal
+	link := c.link
+	c.lock.Unlock()
+	hold := c.config.HoldOnReconnect && c.config.Reconnect.Enabled
+	switch {
+	case connected:
+	case reconnecting && hold:
+	case closed:
+		return ErrClosed
+	default:
+		return ErrNotConnected
+	}
+	if hold {
+		// 斷線時保留在佇列中，連線後再送出
+		closeSignal = nil
+	}
+
+	cmd &^= commandFlagMask
+	// 帶著 ctx 中的 trace，沒有設定 Tracer 時不建立 span
+	trace, inherited := TraceFromContext(ctx)
+	if !tracing() {
+		if !link.traced {
+			trace = TraceContext{}
+		}
+		_, err = c.push(ctx, cmd, body, pb, trace, link, closeSignal, stop)
+		return err
+	}
+	var parent *TraceContext
+	if inherited {
+		parent = &trace
+	}
+	span := startSpan(SpanCommandSend, parent, "cmd", cmd)
+	// 只轉送延續下來的 trace，Tracer 自己產生的 root span 不送出；
+	// 對方沒有在 handshake 接受 trace 時只送基本格式
+	trace = TraceContext{}
+	if link.traced && inherited {
+		trace = span.Context()
+	}
+	length, err := c.push(ctx, cmd, body, pb, trace, link, closeSignal, stop)
+	span.SetAttributes("len", length)
+	span.End(err)
+	return err
 }
 
-//------------------------------------------------------------------------------
-//	Private Methods
-//------------------------------------------------------------------------------
+// push : 編碼到 framePool 的 buffer 後放入送出佇列
+// @param	trace		要寫在表頭之後的 trace，IsValid() 為 false 則不帶
+// @param	link		enqueue 時連線的傳輸功能
+// @param	closeSignal	連線中斷時停止等待，nil 則保留到重新連線
+// @param	stop		Disconnect 時停止等待
+// @return	body 長度 (壓縮前)
+func (c *Connector) push(ctx context.Context, cmd uint32, body []byte, pb proto.Message, trace TraceContext, link linkFeatures, closeSignal, stop chan struct{}) (length int, err error) {
+	frame := getFrame()
+	if frame.data, err = appendCommand(frame.data, cmd, body, pb, trace); err != nil {
+		putFrame(frame)
+		logger(connectorLog).Error("Connector:Send: invalid data. CMD=%d, ERR=%s", cmd, err.Error())
+		return 0, err
+	}
+	length = len(frame.data) - frameBodyOffset(frame.data)
+	if link.deflate && length > c.config.CompressThreshold {
+		var ok bool
+		if frame.data, ok = compressFrame(frame.data); ok {
+			c.stats.compressed(length, len(frame.data)-frameBodyOffset(frame.data))
+		}
+	}
+	size := len(frame.data)
+	if size > c.config.maxCommandSize() || (size > c.config.chunkSize() && !link.chunked) {
+		putFrame(frame)
+		logger(connectorLog).Error("Connector:SendCommand: message too large. CMD=%d, SIZE=%d, CHUNKED=%v", cmd, size, link.chunked)
+		return length, ErrMessageTooLarge
+	}
+	// 送出成功後才計入 stats.sent，見 writeData
+	if ctx == nil {
+		select {
+		case c.message <- frame:
+			return length, nil
+		default:
+			putFrame(frame)
+			logger(connectorLog).Warn("Connector:SendCommand: queue full. CMD=%d, SIZE=%d", cmd, cap(c.message))
+			return length, ErrQueueFull
+		}
+	}
+	select {
+	case c.message <- frame:
+		return length, nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-closeSignal:
+		err = ErrNotConnected
+	case <-stop:
+		err = ErrClosed
+	}
+	putFrame(frame)
+	return length, err
+}
 
-func (c *Connector) readData() {
+// discard : 丟棄送出佇列中的命令
+func (c *Connector) discard() {
+	count := 0
 	for {
-		mt, msg, err := c.conn.ReadMessage()
+		select {
+		case frame := <-c.message:
+			putFrame(frame)
+			c.stats.dropped.Increment()
+			count++
+		default:
+			if count > 0 {
+				logger(connectorLog).Warn("Connector:discard: queued commands discarded. COUNT=%d", count)
+			}
+			return
+		}
+	}
+}
+
+// current : 指定的連線是否仍在使用中
+func (c *Connector) current(conn *websocket.Conn) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.conn == conn
+}
+
+func (c *Connector) readData(conn *websocket.Conn, first []byte) {
+	c.heartbeat(conn)
+	if first != nil && !c.dispatch(conn, first) {
+		return
+	}
+	chunks := &chunkReader{max: c.config.maxCommandSize()}
+	for {
+		mt, msg, err := conn.ReadMessage()
 		if err != nil {
-			Error("Connector:readData: error occur. ERR=%s", err.Error())
-			c.Disconnect()
+			// Disconnect 主動關閉的連線不需要記錄
+			if c.current(conn) {
+				if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+					logger(connectorLog).Warn("Connector:readData: peer not responding. WAIT=%s", c.config.PongWait)
+				} else {
+					logger(connectorLog).Error("Connector:readData: error occur. ERR=%s", err.Error())
+				}
+				c.drop(conn)
+			}
 			return
 		}
+		c.extendDeadline(conn)
 
 		if mt != websocket.BinaryMessage {
-			Error("Connector:readData: read with unknown data. MESSAGE_TYPE=%d", mt)
-			c.Disconnect()
+			logger(connectorLog).Error("Connector:readData: read with unknown data. MESSAGE_TYPE=%d", mt)
+			c.drop(conn)
 			return
 		}
 
-		var cmd *Command
-		if cmd, err = CreateCommand(msg); err != nil {
-			Error("Connector:readData: create command failed. ERR=%s", err.Error())
-			c.Disconnect()
-			return
+		if isChunk(msg) {
+			if msg, err = chunks.feed(msg); err != nil {
+				logger(connectorLog).Error("Connector:readData: invalid chunk. ERR=%s", err.Error())
+				c.drop(conn)
+				return
+			}
+			if msg == nil {
+				continue
+			}
+		}
+
+		frames := [][]byte{msg}
+		if isBatch(msg) {
+			if frames, err = splitBatch(msg); err != nil {
+				logger(connectorLog).Error("Connector:readData: invalid batch. ERR=%s", err.Error())
+				c.drop(conn)
+				return
+			}
+		}
+		for _, frame := range frames {
+			if !c.dispatch(conn, frame) {
+				return
+			}
 		}
-		c.OnCommand(cmd)
 	}
 }
 
-func (c *Connector) writeData() {
+// dispatch : 處理收到的一個封包
+// @return	false 表示封包錯誤，連線已中斷
+func (c *Connector) dispatch(conn *websocket.Conn, frame []byte) bool {
+	cmd, err := CreateCommand(frame)
+	if err != nil {
+		logger(connectorLog).Error("Connector:readData: create command failed. ERR=%s", err.Error())
+		c.drop(conn)
+		return false
+	}
+	if err = cmd.decompress(c.config.maxCommandSize()); err != nil {
+		logger(connectorLog).Error("Connector:readData: decompress failed. CMD=%d, ERR=%s", cmd.Type(), err.Error())
+		c.drop(conn)
+		return false
+	}
+	c.stats.received(cmd.Type(), len(frame))
+	c.OnCommand(cmd)
+	return true
+}
+
+// writeData : 送出佇列中的命令
+// @param	link	此連線協商後的傳輸功能
+func (c *Connector) writeData(conn *websocket.Conn, closeSignal chan struct{}, link linkFeatures) {
+	var ping <-chan time.Time
+	if c.config.PingInterval > 0 {
+		ticker := time.NewTicker(c.config.PingInterval)
+		defer ticker.Stop()
+		ping = ticker.C
+	}
+	batchSize := c.config.batchSize()
+	// 上次合併時放不下的封包
+	var pending *frameBuffer
 	for {
-		select {
-		case <-c.closeSignal:
-			return
+		frame := pending
+		pending = nil
+		if frame == nil {
+			select {
+			case <-closeSignal:
+				return
+
+			case frame = <-c.message:
+				if !c.prepare(frame, link) {
+					continue
+				}
 
-		case body := <-c.message:
-			if err := c.conn.WriteMessage(websocket.BinaryMessage, body); err != nil {
-				Error("Connector:writeData: failed. ERR=%s", err.Error())
-				c.Disconnect()
+			case <-ping:
+				// 帶著送出時間，收到 pong 時計算來回時間
+				var stamp [8]byte
+				binary.LittleEndian.PutUint64(stamp[:], uint64(time.Now().UnixNano()))
+				if err := conn.WriteControl(websocket.PingMessage, stamp[:], time.Now().Add(c.config.writeTimeout())); err != nil {
+					if c.current(conn) {
+						logger(connectorLog).Error("Connector:writeData: ping failed. ERR=%s", err.Error())
+						c.drop(conn)
+					}
+					return
+				}
+				continue
 			}
 		}
+		frames := []*frameBuffer{frame}
+		if link.batched && commandHeaderSize+batchLengthSize+len(frame.data) < batchSize {
+			frames, pending = c.collectBatch(frame, batchSize, c.config.BatchDelay, link)
+		}
+		data := frame.data
+		var batch *frameBuffer
+		if len(frames) > 1 {
+			batch = getFrame()
+			batch.data = appendBatch(batch.data, frames)
+			data = batch.data
+		}
+		ok := c.write(conn, data, link.chunked)
+		for _, f := range frames {
+			if ok {
+				c.stats.sent(frameType(f.data), len(f.data))
+			} else {
+				c.stats.dropped.Increment()
+			}
+			putFrame(f)
+		}
+		if batch != nil {
+			putFrame(batch)
+		}
+		if !ok {
+			if pending != nil {
+				putFrame(pending)
+			}
+			return
+		}
+	}
+}
+
+// write : 送出一個封包，必要時分段
+// @return	false 表示連線已中斷
+func (c *Connector) write(conn *websocket.Conn, body []byte, chunked bool) bool {
+	size := c.config.chunkSize()
+	if len(body) <= size {
+		return c.writeFrame(conn, body)
+	}
+	for _, frame := range splitChunks(body, size) {
+		if !c.writeFrame(conn, frame) {
+			return false
+		}
+	}
+	return true
+}
+
+// writeFrame : 寫出單一 websocket message
+// @return	false 表示連線已中斷
+func (c *Connector) writeFrame(conn *websocket.Conn, frame []byte) bool {
+	conn.SetWriteDeadline(time.Now().Add(c.config.writeTimeout()))
+	if err := conn.WriteMessage(websocket.BinaryMessage, frame); err != nil {
+		if c.current(conn) {
+			logger(connectorLog).Error("Connector:writeData: failed. ERR=%s", err.Error())
+			c.drop(conn)
+		}
+		return false
+	}
+	return true
+}
+
+// prepare : 重新連線前保留的封包是依舊連線的協商編碼，改為此連線可以接受的格式，
+// 仍然無法送出的封包丟棄並計入 stats
+// @return	false 表示無法送出，已丟棄
+func (c *Connector) prepare(frame *frameBuffer, link linkFeatures) bool {
+	flags := binary.LittleEndian.Uint32(frame.data) & commandFlagMask
+	if flags&commandTraceFlag != 0 && !link.traced {
+		frame.data = stripTrace(frame.data)
+	}
+	if flags&commandCompressFlag != 0 && !link.deflate {
+		data, err := inflateFrame(frame.data, c.config.maxCommandSize())
+		if err != nil {
+			logger(connectorLog).Error("Connector:writeData: inflate failed. CMD=%d, ERR=%s", frameType(frame.data), err.Error())
+			c.stats.dropped.Increment()
+			putFrame(frame)
+			return false
+		}
+		frame.data = data
+	}
+	// enqueue 時已檢查過目前的連線，重新連線後對方可能不支援分段
+	if len(frame.data) > c.config.chunkSize() && !link.chunked {
+		logger(connectorLog).Error("Connector:writeData: message too large, peer does not support chunking. CMD=%d, SIZE=%d", frameType(frame.data), len(frame.data))
+		c.stats.dropped.Increment()
+		putFrame(frame)
+		return false
+	}
+	return true
+}
+
+// heartbeat : 設定讀取期限與 ping/pong 處理，在讀取的 goroutine 中呼叫
+func (c *Connector) heartbeat(conn *websocket.Conn) {
+	c.extendDeadline(conn)
+	conn.SetPongHandler(func(data string) error {
+		if len(data) == 8 {
+			sent := int64(binary.LittleEndian.Uint64([]byte(data)))
+			c.stats.pong(time.Since(time.Unix(0, sent)))
+		}
+		c.extendDeadline(conn)
+		return nil
+	})
+	conn.SetPingHandler(func(data string) error {
+		c.extendDeadline(conn)
+		err := conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(c.config.writeTimeout()))
+		if netErr, ok := err.(net.Error); ok && netErr.Temporary() {
+			return nil
+		}
+		if err == websocket.ErrCloseSent {
+			return nil
+		}
+		return err
+	})
+}
+
+// extendDeadline : 收到資料後延長讀取期限
+func (c *Connector) extendDeadline(conn *websocket.Conn) {
+	if c.config.PongWait > 0 {
+		conn.SetReadDeadline(time.Now().Add(c.config.PongWait))
 	}
 }
@@ -0,0 +1,241 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"crypto/tls"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//	Constants
+//------------------------------------------------------------------------------
+
+const (
+	// 預設連線逾時
+	defaultDialTimeout = 10 * time.Second
+	// 預設送出 ping 的間隔
+	defaultPingInterval = 30 * time.Second
+	// 預設等待對方回應的時間，必須大於 ping 的間隔
+	defaultPongWait = 60 * time.Second
+	// 預設寫出逾時
+	defaultWriteTimeout = 10 * time.Second
+	// 預設單一命令的長度上限 (分段組合後)
+	defaultMaxCommandSize = 4 << 20
+	// 預設送出佇列的長度
+	defaultSendQueueSize = 1024
+	// 預設重新連線的最短等待時間
+	defaultReconnectMinDelay = 500 * time.Millisecond
+	// 預設重新連線的最長等待時間
+	defaultReconnectMaxDelay = 30 * time.Second
+	// 預設重新連線等待時間的倍數
+	defaultReconnectMultiplier = 2.0
+)
+
+//------------------------------------------------------------------------------
+//	Structure declare
+//------------------------------------------------------------------------------
+
+type (
+	// ReconnectPolicy : 斷線後自動重新連線的設定，使用指數退避
+	ReconnectPolicy struct {
+		Enabled    bool          // 是否自動重新連線，Disconnect 主動斷線時不會重新連線
+		MaxRetries int           // 連續失敗幾次後放棄，0 則不限次數
+		MinDelay   time.Duration // 第一次重試前的等待時間，0 則為 500ms
+		MaxDelay   time.Duration // 等待時間的上限，0 則為 30 秒
+		Multiplier float64       // 每次失敗後等待時間的倍數，<= 1 則為 2
+		Jitter     float64       // 等待時間隨機增減的比例，0 ~ 1
+	}
+
+	// ConnectorConfig : Connector 的設定，ex: DefaultConnectorConfig("127.0.0.1:8600")
+	ConnectorConfig struct {
+		URL               string          // 連線位置，ex: "ws://127.0.0.1:8600/"，沒有 scheme 時視為 host:port
+		DialTimeout       time.Duration   // 連線 (包含 websocket upgrade) 的逾時，0 則為 10 秒
+		HandshakeTimeout  time.Duration   // 等待 handshake 回應的時間，0 則為 5 秒
+		ReadLimit         int64           // 單一 websocket 訊息的最大長度，0 則為 5120
+		ChunkSize         int             // 超過此長度的命令分段送出，不可超過對方的 ReadLimit，0 則為 5120
+		MaxCommandSize    int             // 單一命令 (分段組合後) 的最大長度，超過則拒絕送出或中斷連線，0 則為 4MB
+		WriteBufferSize   int             // websocket 寫出緩衝大小，0 則使用 websocket 預設值
+		Header            http.Header     // 連線時額外帶的 request header
+		PingInterval      time.Duration   // 送出 ping 的間隔，0 則不送
+		PongWait          time.Duration   // 超過此時間沒有收到任何資料 (包含 pong) 則視為斷線，0 則不檢查，必須大於 PingInterval
+		WriteTimeout      time.Duration   // 單次寫出的逾時，0 則為 10 秒
+		CompressThreshold int             // 超過此長度的 body 壓縮後送出，雙方皆設定時才會啟用，0 則不壓縮
+		BatchSize         int             // 合併送出的長度上限，不超過 ChunkSize，雙方皆設定時才會啟用，0 則不合併
+		BatchDelay        time.Duration   // 合併時等待更多命令的時間，0 則只合併已在佇列中的
+		SendQueueSize     int             // 送出佇列的長度，0 則為 1024
+		HoldOnReconnect   bool            // 重新連線期間保留送出的命令，連線後依新的協商調整格式再送出；否則回傳 ErrNotConnected
+		Reconnect         ReconnectPolicy // 斷線後重新連線的設定
+		Handshake         *HandshakeData  // 連線後送出的 handshake，nil 則不進行 handshake
+		Auth              *ConnectorAuth  // 驗證設定，nil 則不驗證
+		TLSConfig         *tls.Config     // 設定後以 wss:// 連線，ex: NewClientTLSConfig
+	}
+)
+
+//------------------------------------------------------------------------------
+//	Public Methods
+//------------------------------------------------------------------------------
+
+// DefaultConnectorConfig : 取得預設的設定
+// @param	address	AgencyService 的 host:port
+func DefaultConnectorConfig(address string) ConnectorConfig {
+	return ConnectorConfig{
+		URL:           "ws://" + address + "/",
+		DialTimeout:   defaultDialTimeout,
+		ReadLimit:     maxMessageSize,
+		PingInterval:  defaultPingInterval,
+		PongWait:      defaultPongWait,
+		WriteTimeout:  defaultWriteTimeout,
+		SendQueueSize: defaultSendQueueSize,
+		Reconnect: ReconnectPolicy{
+			MinDelay:   defaultReconnectMinDelay,
+			MaxDelay:   defaultReconnectMaxDelay,
+			Multiplier: defaultReconnectMultiplier,
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+// url : 解析連線位置，有 TLSConfig 時使用 wss
+func (c *ConnectorConfig) url() (*url.URL, error) {
+	raw := c.URL
+	if !strings.Contains(raw, "://") {
+		raw = "ws://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	if c.TLSConfig != nil && u.Scheme == "ws" {
+		u.Scheme = "wss"
+	}
+	return u, nil
+}
+
+func (c *ConnectorConfig) dialTimeout() time.Duration {
+	if c.DialTimeout <= 0 {
+		return defaultDialTimeout
+	}
+	return c.DialTimeout
+}
+
+func (c *ConnectorConfig) handshakeTimeout() time.Duration {
+	if c.HandshakeTimeout <= 0 {
+		return defaultHandshakeTimeout
+	}
+	return c.HandshakeTimeout
+}
+
+func (c *ConnectorConfig) writeTimeout() time.Duration {
+	if c.WriteTimeout <= 0 {
+		return defaultWriteTimeout
+	}
+	return c.WriteTimeout
+}
+
+func (c *ConnectorConfig) chunkSize() int {
+	if c.ChunkSize <= chunkHeaderSize {
+		return int(maxMessageSize)
+	}
+	return c.ChunkSize
+}
+
+func (c *ConnectorConfig) maxCommandSize() int {
+	if c.MaxCommandSize <= 0 {
+		return defaultMaxCommandSize
+	}
+	return c.MaxCommandSize
+}
+
+// features : handshake 時要求啟用的傳輸功能
+func (c *ConnectorConfig) features() []string {
+	res := []string{FeatureTrace}
+	if c.CompressThreshold > 0 {
+		res = append(res, FeatureDeflate)
+	}
+	if c.BatchSize > 0 {
+		res = append(res, FeatureBatch)
+	}
+	return res
+}
+
+func (c *ConnectorConfig) batchSize() int {
+	if size := c.chunkSize(); c.BatchSize > size {
+		return size
+	}
+	return c.BatchSize
+}
+
+func (c *ConnectorConfig) sendQueueSize() int {
+	if c.SendQueueSize <= 0 {
+		return defaultSendQueueSize
+	}
+	return c.SendQueueSize
+}
+
+func (c *ConnectorConfig) readLimit() int64 {
+	if c.ReadLimit <= 0 {
+		return maxMessageSize
+	}
+	return c.ReadLimit
+}
+
+//------------------------------------------------------------------------------
+
+// delay : 第 attempt 次 (從 1 開始) 重試前的等待時間
+func (p *ReconnectPolicy) delay(attempt int) time.Duration {
+	min, max, multiplier := p.MinDelay, p.MaxDelay, p.Multiplier
+	if min <= 0 {
+		min = defaultReconnectMinDelay
+	}
+	if max <= 0 {
+		max = defaultReconnectMaxDelay
+	}
+	if multiplier <= 1 {
+		multiplier = defaultReconnectMultiplier
+	}
+	delay := float64(min)
+	for i := 1; i < attempt && delay < float64(max); i++ {
+		delay *= multiplier
+	}
+	if delay > float64(max) {
+		delay = float64(max)
+	}
+	if p.Jitter > 0 {
+		jitter := p.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+		delay += delay * jitter * (rand.Float64()*2 - 1)
+	}
+	return time.Duration(delay)
+}
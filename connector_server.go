@@ -0,0 +1,259 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+//------------------------------------------------------------------------------
+//	Structure declare
+//------------------------------------------------------------------------------
+
+type (
+	// HandshakeHandler : 驗證 client 的 handshake，回傳 error 則拒絕連線，error 內容會回給 client
+	HandshakeHandler func(req *HandshakeData) error
+
+	// ServerCommandHandler : ConnectorServer 收命令用的 method
+	ServerCommandHandler func(c *Connector, cmd *Command)
+
+	// ConnectorServer : 接受 Connector 連線的 websocket 服務 (AgencyService 端)。
+	// 每個連線在 handshake 成功後包成一個 Connector，開始收送後透過 OnConnect 交給應用程式。
+	// 第一個封包不是 handshake 的舊版 client 以協定 v1 接受 (Version() 為 0)，沒有送出任何封包的 client 在 HandshakeTimeout 後斷線
+	ConnectorServer struct {
+		address  string
+		server   *http.Server
+		upgrader websocket.Upgrader
+		running  *InterlockBool
+		conns    *ConcurrentMap // *Connector -> struct{}
+
+		Version          uint32               // 支援的最高協定版本，0 則為 ProtocolVersion
+		MinVersion       uint32               // 支援的最低協定版本，0 則為 MinProtocolVersion
+		CommandSets      []string             // 支援的命令集，nil 則接受 client 所有的
+		HandshakeTimeout time.Duration        // 等待 handshake 的時間，0 則為 5 秒
+		OnHandshake      HandshakeHandler     // 驗證 handshake，nil 則全部接受
+		Auth             *ServerAuth          // 驗證設定，nil 則不驗證
+		TLSConfig        *tls.Config          // 設定後以 wss:// 服務，ex: NewServerTLSConfig
+		Connector        ConnectorConfig      // 接受的連線使用的設定，URL、Handshake 與重新連線無效
+		CommandHandler   ServerCommandHandler // 所有連線收到的命令，在開始讀取前設定，OnConnect 中不可再修改 Connector.CommandHandler
+		OnConnect        func(c *Connector)   // 連線開始收送後呼叫，可以直接 Send
+	}
+)
+
+//------------------------------------------------------------------------------
+//	Public Methods
+//------------------------------------------------------------------------------
+
+// NewConnectorServer : ConnectorServer object creator.
+// @param	address	listen 位置，ex: ":8600"
+func NewConnectorServer(address string) *ConnectorServer {
+	return &ConnectorServer{
+		address:   address,
+		running:   NewInterlockBool(false),
+		conns:     NewConcurrentMap(),
+		Connector: DefaultConnectorConfig(address),
+	}
+}
+
+// Address : 取得 listen 位置
+func (s *ConnectorServer) Address() string {
+	return s.address
+}
+
+// Start : 開始 listen，服務在獨立的 goroutine 中執行
+func (s *ConnectorServer) Start() error {
+	if s.running.Exchange(true) {
+		return errors.New("connector server already started")
+	}
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		s.running.False()
+		logger(connectorLog).Error("ConnectorServer:Start: listen failed. ADDR=%s, ERR=%s", s.address, err.Error())
+		return err
+	}
+	s.address = listener.Addr().String()
+	if s.TLSConfig != nil {
+		listener = tls.NewListener(listener, s.TLSConfig)
+	}
+	s.server = &http.Server{Handler: s}
+	go func() {
+		if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logger(connectorLog).Error("ConnectorServer:Start: serve failed. ERR=%s", err.Error())
+		}
+	}()
+	logger(connectorLog).Notice("ConnectorServer:Start: listen. ADDR=%s", s.address)
+	return nil
+}
+
+// Shutdown : 停止服務並中斷所有連線
+func (s *ConnectorServer) Shutdown() {
+	if !s.running.Exchange(false) {
+		logger(connectorLog).Error("ConnectorServer:Shutdown: not start.")
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.server.Shutdown(ctx); err != nil {
+		logger(connectorLog).Error("ConnectorServer:Shutdown: error occur. ERR=%s", err.Error())
+	}
+	for _, c := range s.Connectors() {
+		c.Disconnect()
+	}
+}
+
+// Connectors : 取得目前的連線
+func (s *ConnectorServer) Connectors() []*Connector {
+	pairs := s.conns.GetSnapshot()
+	res := make([]*Connector, len(pairs))
+	for i, pair := range pairs {
+		res[i] = pair.Key.(*Connector)
+	}
+	return res
+}
+
+// ServeHTTP : implement http.Handler，可以掛在其他的 http 服務下
+func (s *ConnectorServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.Auth != nil {
+		if err := s.Auth.verifyRequest(r); err != nil {
+			logger(connectorLog).Warn("ConnectorServer:ServeHTTP: unauthorized. ADDR=%s, ERR=%s", r.RemoteAddr, err.Error())
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+	}
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger(connectorLog).Error("ConnectorServer:ServeHTTP: upgrade failed. ADDR=%s, ERR=%s", r.RemoteAddr, err.Error())
+		return
+	}
+	conn.SetReadLimit(s.Connector.readLimit())
+	c, err := s.accept(conn)
+	if err != nil {
+		logger(connectorLog).Warn("ConnectorServer:ServeHTTP: handshake failed. ADDR=%s, ERR=%s", r.RemoteAddr, err.Error())
+		conn.Close()
+		return
+	}
+	c.tlsState = r.TLS
+	logger(connectorLog).Info("ConnectorServer:ServeHTTP: accepted. ADDR=%s, SERVICE=%s, INSTANCE=%s, VERSION=%d", r.RemoteAddr, c.peer.Service, c.peer.Instance, c.version)
+	s.conns.Set(c, struct{}{})
+	c.onClose = func() { s.conns.Remove(c) }
+	// 開始讀取前設定 CommandHandler，避免漏掉命令
+	if handler := s.CommandHandler; handler != nil {
+		c.CommandHandler = func(cmd *Command) { handler(c, cmd) }
+	}
+	c.attach(conn, nil, nil)
+	if s.OnConnect != nil {
+		s.OnConnect(c)
+	}
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+// accept : 讀取 handshake、協商並回應
+func (s *ConnectorServer) accept(conn *websocket.Conn) (*Connector, error) {
+	timeout := s.HandshakeTimeout
+	if timeout <= 0 {
+		timeout = defaultHandshakeTimeout
+	}
+	frame, first, err := readFirst(conn, timeout)
+	if err != nil {
+		return nil, err
+	}
+	version, minVersion := s.Version, s.MinVersion
+	if version == 0 {
+		version = ProtocolVersion
+	}
+	if minVersion == 0 {
+		minVersion = MinProtocolVersion
+	}
+	if first.Type() != CommandHandshake {
+		return s.acceptLegacy(conn, frame, minVersion)
+	}
+	req := &HandshakeData{}
+	if err = parseHandshake(first, CommandHandshake, req); err != nil {
+		return nil, err
+	}
+	if s.Auth != nil {
+		if err := s.Auth.verifyHandshake(req); err != nil {
+			// 不將細節告訴對方
+			writeHandshake(conn, timeout, CommandHandshakeAck, &HandshakeAckData{Reason: ErrUnauthorized.Error()})
+			return nil, fmt.Errorf("%w: %s", ErrUnauthorized, err.Error())
+		}
+	}
+	ack, err := negotiate(req, version, minVersion, s.CommandSets, s.Connector.features())
+	if err == nil && s.OnHandshake != nil {
+		err = s.OnHandshake(req)
+	}
+	if err != nil {
+		writeHandshake(conn, timeout, CommandHandshakeAck, &HandshakeAckData{Reason: err.Error()})
+		return nil, fmt.Errorf("%w: %s", ErrHandshakeRejected, err.Error())
+	}
+	if err = writeHandshake(conn, timeout, CommandHandshakeAck, ack); err != nil {
+		return nil, err
+	}
+	c := s.newConnector(conn, req)
+	c.version = ack.Version
+	c.commandSets = ack.CommandSets
+	c.features = ack.Features
+	return c, nil
+}
+
+// acceptLegacy : 第一個封包不是 handshake 時，視為沒有 handshake 的舊版 client，以基本協定 (v1) 接受。
+// 該封包在開始讀取時先處理；需要簽章或不支援 v1 時拒絕
+// @param	frame	第一個封包
+func (s *ConnectorServer) acceptLegacy(conn *websocket.Conn, frame []byte, minVersion uint32) (*Connector, error) {
+	if s.Auth != nil && len(s.Auth.Secret) > 0 {
+		return nil, fmt.Errorf("%w: missing handshake signature", ErrUnauthorized)
+	}
+	if minVersion > MinProtocolVersion {
+		return nil, fmt.Errorf("%w: no handshake, version %d required", ErrHandshakeRejected, minVersion)
+	}
+	peer := &HandshakeData{Version: MinProtocolVersion, MinVersion: MinProtocolVersion}
+	if s.OnHandshake != nil {
+		if err := s.OnHandshake(peer); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrHandshakeRejected, err.Error())
+		}
+	}
+	c := s.newConnector(conn, peer)
+	c.first = frame
+	return c, nil
+}
+
+// newConnector : 以 s.Connector 的設定包裝接受的連線
+func (s *ConnectorServer) newConnector(conn *websocket.Conn, peer *HandshakeData) *Connector {
+	config := s.Connector
+	config.URL = conn.RemoteAddr().String()
+	config.Handshake = nil
+	config.Reconnect.Enabled = false
+	c := NewConnector(config)
+	c.peer = peer
+	return c
+}
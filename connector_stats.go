@@ -0,0 +1,156 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//	Structure declare
+//------------------------------------------------------------------------------
+
+type (
+	// CommandStats : 單一命令型別的收送統計
+	CommandStats struct {
+		Type     uint32 // 命令型別
+		InCount  int64  // 收到次數
+		InBytes  int64  // 收到 bytes (包含 header)
+		OutCount int64  // 送出次數
+		OutBytes int64  // 送出 bytes (包含 header)
+	}
+
+	// ConnectorStats : Connector 連線統計
+	ConnectorStats struct {
+		Connected  bool           // 目前是否連線中
+		Connects   int64          // 成功連線次數
+		Reconnects int64          // 重新連線次數
+		Latency    time.Duration  // 最近一次 ping 的來回時間，0 表示尚未量測
+		Queued     int            // 送出佇列中等待的命令數
+		Dropped    int64          // 沒有送出就丟棄的命令數 (斷線、重新連線後無法送出等)
+		RawBytes   int64          // 壓縮送出的命令，壓縮前的 body bytes
+		Compressed int64          // 壓縮送出的命令，壓縮後的 body bytes
+		InCount    int64          // 收到命令總數
+		InBytes    int64          // 收到 bytes 總數
+		OutCount   int64          // 送出命令總數
+		OutBytes   int64          // 送出 bytes 總數
+		Commands   []CommandStats // 依命令型別的統計，依型別排序
+	}
+
+	connectorStats struct {
+		online   *InterlockBool
+		connects *InterlockInt64
+		latency  *InterlockInt64
+		raw      *InterlockInt64
+		deflated *InterlockInt64
+		dropped  *InterlockInt64
+		commands map[uint32]*CommandStats
+		sync.Mutex
+	}
+)
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+func newConnectorStats() *connectorStats {
+	return &connectorStats{
+		online:   NewInterlockBool(false),
+		connects: NewInterlockInt64(0),
+		latency:  NewInterlockInt64(0),
+		raw:      NewInterlockInt64(0),
+		deflated: NewInterlockInt64(0),
+		dropped:  NewInterlockInt64(0),
+		commands: make(map[uint32]*CommandStats),
+	}
+}
+
+func (s *connectorStats) connected() {
+	s.online.True()
+	s.connects.Increment()
+}
+
+func (s *connectorStats) disconnected() {
+	s.online.False()
+}
+
+func (s *connectorStats) pong(rtt time.Duration) {
+	s.latency.Exchange(int64(rtt))
+}
+
+func (s *connectorStats) compressed(raw, size int) {
+	s.raw.Add(int64(raw))
+	s.deflated.Add(int64(size))
+}
+
+func (s *connectorStats) command(cmd uint32) *CommandStats {
+	stats, ok := s.commands[cmd]
+	if !ok {
+		stats = &CommandStats{Type: cmd}
+		s.commands[cmd] = stats
+	}
+	return stats
+}
+
+func (s *connectorStats) received(cmd uint32, size int) {
+	s.Lock()
+	defer s.Unlock()
+	stats := s.command(cmd)
+	stats.InCount++
+	stats.InBytes += int64(size)
+}
+
+func (s *connectorStats) sent(cmd uint32, size int) {
+	s.Lock()
+	defer s.Unlock()
+	stats := s.command(cmd)
+	stats.OutCount++
+	stats.OutBytes += int64(size)
+}
+
+func (s *connectorStats) snapshot() ConnectorStats {
+	res := ConnectorStats{
+		Connected:  s.online.Value(),
+		Connects:   s.connects.Value(),
+		Latency:    time.Duration(s.latency.Value()),
+		RawBytes:   s.raw.Value(),
+		Compressed: s.deflated.Value(),
+		Dropped:    s.dropped.Value(),
+	}
+	if res.Connects > 1 {
+		res.Reconnects = res.Connects - 1
+	}
+	s.Lock()
+	res.Commands = make([]CommandStats, 0, len(s.commands))
+	for _, stats := range s.commands {
+		res.Commands = append(res.Commands, *stats)
+		res.InCount += stats.InCount
+		res.InBytes += stats.InBytes
+		res.OutCount += stats.OutCount
+		res.OutBytes += stats.OutBytes
+	}
+	s.Unlock()
+	sort.Slice(res.Commands, func(i, j int) bool { return res.Commands[i].Type < res.Commands[j].Type })
+	return res
+}
@@ -0,0 +1,200 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//	Tests
+//------------------------------------------------------------------------------
+
+func TestPrepareHeldFrame(t *testing.T) {
+	body := bytes.Repeat([]byte("agency "), 64)
+	tc := NewTraceContext()
+	plain, _ := appendCommand(nil, 7, body, nil, TraceContext{})
+
+	// 舊連線協商了 trace 與 deflate
+	frame := getFrame()
+	frame.data, _ = appendCommand(frame.data, 7, body, nil, tc)
+	var ok bool
+	if frame.data, ok = compressFrame(frame.data); !ok {
+		t.Fatal("frame not compressed")
+	}
+	c := NewConnector(DefaultConnectorConfig("127.0.0.1:0"))
+	if !c.prepare(frame, linkFeatures{traced: true, deflate: true}) {
+		t.Fatal("frame dropped")
+	}
+	flags := commandTraceFlag | commandCompressFlag
+	if binary.LittleEndian.Uint32(frame.data)&flags != flags {
+		t.Fatal("flags removed on a link that supports them")
+	}
+
+	// 新連線兩者皆未協商
+	if !c.prepare(frame, linkFeatures{}) {
+		t.Fatal("frame dropped")
+	}
+	if !bytes.Equal(frame.data, plain) {
+		t.Fatalf("unexpected frame. GOT=%x, WANT=%x", frame.data[:commandHeaderSize], plain[:commandHeaderSize])
+	}
+	putFrame(frame)
+}
+
+func TestPrepareOversizeFrame(t *testing.T) {
+	config := DefaultConnectorConfig("127.0.0.1:0")
+	config.ChunkSize = 64
+	c := NewConnector(config)
+	frame := getFrame()
+	frame.data, _ = appendCommand(frame.data, 7, make([]byte, 128), nil, TraceContext{})
+	if c.prepare(frame, linkFeatures{}) {
+		t.Fatal("oversize frame should be dropped when the peer does not support chunking")
+	}
+	if dropped := c.GetStats().Dropped; dropped != 1 {
+		t.Fatalf("unexpected dropped count %d", dropped)
+	}
+
+	frame = getFrame()
+	frame.data, _ = appendCommand(frame.data, 7, make([]byte, 128), nil, TraceContext{})
+	if !c.prepare(frame, linkFeatures{chunked: true}) {
+		t.Fatal("oversize frame dropped on a chunked link")
+	}
+	putFrame(frame)
+}
+
+func TestConnectorStatsCountWritten(t *testing.T) {
+	received := make(chan struct{}, 16)
+	server := startTestServer(t, func(c *Connector, cmd *Command) { received <- struct{}{} })
+	c := connectTestClient(t, server)
+	for i := 0; i < 10; i++ {
+		if err := c.SendCommand(7, []byte("hello")); err != nil {
+			t.Fatalf("send failed: %v", err)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		select {
+		case <-received:
+		case <-time.After(5 * time.Second):
+			t.Fatal("command not received")
+		}
+	}
+	stats := c.GetStats()
+	if stats.OutCount != 10 || stats.OutBytes != 10*(commandHeaderSize+5) || stats.Dropped != 0 {
+		t.Fatalf("unexpected stats. OUT=%d, BYTES=%d, DROPPED=%d", stats.OutCount, stats.OutBytes, stats.Dropped)
+	}
+}
+
+func TestLegacyClientWithoutHandshake(t *testing.T) {
+	received := make(chan uint32, 16)
+	accepted := make(chan *Connector, 1)
+	server := NewConnectorServer("127.0.0.1:0")
+	server.CommandHandler = func(c *Connector, cmd *Command) { received <- cmd.Type() }
+	server.OnConnect = func(c *Connector) { accepted <- c }
+	if err := server.Start(); err != nil {
+		t.Fatalf("start server failed: %v", err)
+	}
+	defer server.Shutdown()
+
+	// 沒有設定 Handshake 的 client 直接送出命令
+	c := NewConnector(DefaultConnectorConfig(server.Address()))
+	if err := c.Connect(); err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	defer c.Disconnect()
+	for i := uint32(1); i <= 3; i++ {
+		if err := c.SendCommand(i, []byte("legacy")); err != nil {
+			t.Fatalf("send failed: %v", err)
+		}
+	}
+	for want := uint32(1); want <= 3; want++ {
+		select {
+		case got := <-received:
+			if got != want {
+				t.Fatalf("unexpected command. GOT=%d, WANT=%d", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("command %d not received", want)
+		}
+	}
+	peer := <-accepted
+	if peer.Version() != 0 || peer.Peer().Version != MinProtocolVersion {
+		t.Fatalf("unexpected version %d, peer %d", peer.Version(), peer.Peer().Version)
+	}
+}
+
+func TestLegacyClientRejected(t *testing.T) {
+	server := NewConnectorServer("127.0.0.1:0")
+	server.MinVersion = 2
+	if err := server.Start(); err != nil {
+		t.Fatalf("start server failed: %v", err)
+	}
+	defer server.Shutdown()
+
+	c := NewConnector(DefaultConnectorConfig(server.Address()))
+	if err := c.Connect(); err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	defer c.Disconnect()
+	c.SendCommand(1, []byte("legacy"))
+	deadline := time.Now().Add(5 * time.Second)
+	for c.GetStats().Connected {
+		if time.Now().After(deadline) {
+			t.Fatal("legacy client not rejected by a server requiring version 2")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := len(server.Connectors()); n != 0 {
+		t.Fatalf("unexpected connection count %d", n)
+	}
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+// startTestServer : 在隨機 port 啟動 ConnectorServer
+func startTestServer(t *testing.T, handler ServerCommandHandler) *ConnectorServer {
+	t.Helper()
+	server := NewConnectorServer("127.0.0.1:0")
+	server.CommandHandler = handler
+	if err := server.Start(); err != nil {
+		t.Fatalf("start server failed: %v", err)
+	}
+	t.Cleanup(server.Shutdown)
+	return server
+}
+
+// connectTestClient : 以 handshake 連線到 server
+func connectTestClient(t *testing.T, server *ConnectorServer) *Connector {
+	t.Helper()
+	config := DefaultConnectorConfig(server.Address())
+	config.Handshake = &HandshakeData{Service: "connector_test"}
+	c := NewConnector(config)
+	if err := c.Connect(); err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	t.Cleanup(c.Disconnect)
+	return c
+}
@@ -0,0 +1,271 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+// Enumeration
+//------------------------------------------------------------------------------
+
+type workOutcome int
+
+const (
+	workSucceeded workOutcome = iota
+	workErrored
+	workPanicked
+)
+
+//------------------------------------------------------------------------------
+//	Variables
+//------------------------------------------------------------------------------
+
+var (
+	// DefaultLatencyBuckets : Histogram 預設的區間上限
+	DefaultLatencyBuckets = []time.Duration{
+		100 * time.Microsecond,
+		500 * time.Microsecond,
+		time.Millisecond,
+		5 * time.Millisecond,
+		10 * time.Millisecond,
+		50 * time.Millisecond,
+		100 * time.Millisecond,
+		500 * time.Millisecond,
+		time.Second,
+		5 * time.Second,
+		10 * time.Second,
+	}
+)
+
+//------------------------------------------------------------------------------
+//	Structure declare
+//------------------------------------------------------------------------------
+
+type (
+	// Histogram : 固定區間的耗時分佈統計
+	Histogram struct {
+		Bounds []time.Duration // 各區間上限 (包含)
+		Counts []int64         // 各區間計數，最後一個為超過所有上限者
+		Count  int64           // 總次數
+		Sum    time.Duration   // 總耗時
+		Max    time.Duration   // 最大耗時
+	}
+
+	// HandlerMetrics : 以 handler 名稱彙整的執行統計
+	HandlerMetrics struct {
+		Name      string    // handler 名稱，同 TaskInfo.Caller
+		Count     int64     // 執行次數
+		Errors    int64     // 回傳 error 的次數
+		Panics    int64     // panic 的次數
+		QueueWait Histogram // 從送出到開始執行的時間
+		Execution Histogram // 執行時間
+		Blocked   Histogram // 被 barrier 或前置工作阻擋的時間，只計入有被阻擋的執行
+		Since     time.Time // 統計開始時間
+	}
+
+	handlerStats struct {
+		data HandlerMetrics
+		sync.Mutex
+	}
+)
+
+//------------------------------------------------------------------------------
+//	Public Methods
+//------------------------------------------------------------------------------
+
+// NewHistogram : Histogram object creator.
+// @param	bounds	各區間上限，必須由小到大；nil 則使用 DefaultLatencyBuckets
+func NewHistogram(bounds []time.Duration) Histogram {
+	if bounds == nil {
+		bounds = DefaultLatencyBuckets
+	}
+	return Histogram{
+		Bounds: bounds,
+		Counts: make([]int64, len(bounds)+1),
+	}
+}
+
+// Mean : 平均耗時
+func (h *Histogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// Quantile : 以區間內線性內插估算百分位數
+// @param	q	0 ~ 1, ex: 0.99 表示 p99
+func (h *Histogram) Quantile(q float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	if q < 0 {
+		q = 0
+	} else if q > 1 {
+		q = 1
+	}
+	rank := q * float64(h.Count)
+	var seen int64
+	for i, n := range h.Counts {
+		if n == 0 {
+			continue
+		}
+		if float64(seen+n) < rank {
+			seen += n
+			continue
+		}
+		if i == len(h.Bounds) {
+			// 超過最後一個上限，只能回傳最大值
+			return h.Max
+		}
+		var lower time.Duration
+		if i > 0 {
+			lower = h.Bounds[i-1]
+		}
+		upper := h.Bounds[i]
+		if h.Max < upper {
+			upper = h.Max
+		}
+		ratio := (rank - float64(seen)) / float64(n)
+		return lower + time.Duration(ratio*float64(upper-lower))
+	}
+	return h.Max
+}
+
+// GetHandlerMetrics : 取得以 handler 名稱彙整的執行統計，依名稱排序
+func (p *poolManager) GetHandlerMetrics() []HandlerMetrics {
+	p.statsLock.RLock()
+	res := make([]HandlerMetrics, 0, len(p.handlerStats))
+	for _, stats := range p.handlerStats {
+		res = append(res, stats.snapshot())
+	}
+	p.statsLock.RUnlock()
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res
+}
+
+// GetHandlerMetric : 取得指定 handler 的執行統計
+// @param	name	handler 名稱，同 TaskInfo.Caller
+// @return	執行統計 & 是否有此 handler 的紀錄
+func (p *poolManager) GetHandlerMetric(name string) (HandlerMetrics, bool) {
+	p.statsLock.RLock()
+	stats, ok := p.handlerStats[name]
+	p.statsLock.RUnlock()
+	if !ok {
+		return HandlerMetrics{}, false
+	}
+	return stats.snapshot(), true
+}
+
+// ResetHandlerMetrics : 清除所有 handler 的執行統計
+func (p *poolManager) ResetHandlerMetrics() {
+	p.statsLock.Lock()
+	defer p.statsLock.Unlock()
+	p.handlerStats = make(map[string]*handlerStats)
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+func (h *Histogram) observe(d time.Duration) {
+	i := sort.Search(len(h.Bounds), func(i int) bool { return d <= h.Bounds[i] })
+	h.Counts[i]++
+	h.Count++
+	h.Sum += d
+	if h.Max < d {
+		h.Max = d
+	}
+}
+
+func (h *Histogram) clone() Histogram {
+	res := *h
+	res.Counts = make([]int64, len(h.Counts))
+	copy(res.Counts, h.Counts)
+	return res
+}
+
+//------------------------------------------------------------------------------
+
+func newHandlerStats(name string) *handlerStats {
+	return &handlerStats{
+		data: HandlerMetrics{
+			Name:      name,
+			QueueWait: NewHistogram(nil),
+			Execution: NewHistogram(nil),
+			Blocked:   NewHistogram(nil),
+			Since:     time.Now(),
+		},
+	}
+}
+
+func (s *handlerStats) snapshot() HandlerMetrics {
+	s.Lock()
+	defer s.Unlock()
+	res := s.data
+	res.QueueWait = s.data.QueueWait.clone()
+	res.Execution = s.data.Execution.clone()
+	res.Blocked = s.data.Blocked.clone()
+	return res
+}
+
+//------------------------------------------------------------------------------
+
+func (p *poolManager) getHandlerStats(name string) *handlerStats {
+	p.statsLock.RLock()
+	stats, ok := p.handlerStats[name]
+	p.statsLock.RUnlock()
+	if ok {
+		return stats
+	}
+	p.statsLock.Lock()
+	defer p.statsLock.Unlock()
+	if stats, ok = p.handlerStats[name]; !ok {
+		stats = newHandlerStats(name)
+		p.handlerStats[name] = stats
+	}
+	return stats
+}
+
+func (p *poolManager) recordWork(work *Task, outcome workOutcome) {
+	wait := work.invokeAt.Sub(work.submitAt)
+	exec := time.Since(work.invokeAt)
+	stats := p.getHandlerStats(work.name)
+	stats.Lock()
+	defer stats.Unlock()
+	stats.data.Count++
+	switch outcome {
+	case workErrored:
+		stats.data.Errors++
+	case workPanicked:
+		stats.data.Panics++
+	}
+	stats.data.QueueWait.observe(wait)
+	stats.data.Execution.observe(exec)
+	if work.blocked > 0 {
+		stats.data.Blocked.observe(work.blocked)
+	}
+}
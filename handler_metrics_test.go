@@ -0,0 +1,76 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"testing"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//	Tests
+//------------------------------------------------------------------------------
+
+func TestBlockedMetrics(t *testing.T) {
+	startTestPool()
+	gate := make(chan struct{})
+	first := PoolManager.SendWork(metricsTestGate, gate)
+	PoolManager.SendWorkAfter([]*Task{first}, metricsTestNoop)
+	PoolManager.SendWork(metricsTestFree)
+	PoolManager.SendWork(metricsTestFree)
+	time.Sleep(20 * time.Millisecond)
+	close(gate)
+
+	free := waitHandlerMetric(t, "agency.metricsTestFree", 2)
+	if free.Blocked.Count != 0 {
+		t.Fatalf("unblocked tasks observed as blocked. COUNT=%d", free.Blocked.Count)
+	}
+	dependent := waitHandlerMetric(t, "agency.metricsTestNoop", 1)
+	if dependent.Blocked.Count != 1 || dependent.Blocked.Sum < 20*time.Millisecond {
+		t.Fatalf("dependency wait not observed. COUNT=%d, SUM=%s", dependent.Blocked.Count, dependent.Blocked.Sum)
+	}
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+func metricsTestGate(gate chan struct{}) { <-gate }
+
+func metricsTestNoop() {}
+
+func metricsTestFree() {}
+
+// waitHandlerMetric : 等待 handler 執行指定次數後取得統計
+func waitHandlerMetric(t *testing.T, name string, count int64) HandlerMetrics {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if m, ok := PoolManager.GetHandlerMetric(name); ok && m.Count >= count {
+			return m
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("handler %s not invoked %d times", name, count)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,198 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gorilla/websocket"
+)
+
+//------------------------------------------------------------------------------
+//	Constants
+//------------------------------------------------------------------------------
+
+const (
+	// ProtocolVersion : 目前支援的最高協定版本
+	//	1: 基本版本，cmd(4) | length(4) | body
+	//	2: 超過 ChunkSize 的封包分段送出
+	// cmd 的高 4 個位元為旗標，只有在 handshake 協商後才會送出，未協商的對方只會收到基本格式:
+	//	bit31 trace   (FeatureTrace)   cmd | length | trace id(16) | span id(8) | flags(1) | body
+	//	bit30 chunk   (version >= 2)   cmd | length | total(4) | data，見 chunkReader
+	//	bit29 deflate (FeatureDeflate) cmd | length | deflate(body)
+	//	bit28 batch   (FeatureBatch)   cmd | length | [length(4) | 封包]...
+	ProtocolVersion uint32 = 2
+	// MinProtocolVersion : 目前支援的最低協定版本
+	MinProtocolVersion uint32 = 1
+
+	// 0x0FFF0000 ~ 0x0FFFFFFF 保留給控制命令
+	// CommandHandshake : microservice -> AgencyService, HandshakeData
+	CommandHandshake uint32 = 0x0FFF0001
+	// CommandHandshakeAck : AgencyService -> microservice, HandshakeAckData
+	CommandHandshakeAck uint32 = 0x0FFF0002
+
+	// 預設等待 handshake 的時間
+	defaultHandshakeTimeout = 5 * time.Second
+)
+
+//------------------------------------------------------------------------------
+//	Variables
+//------------------------------------------------------------------------------
+
+var (
+	// ErrHandshakeRejected : 對方拒絕連線
+	ErrHandshakeRejected = errors.New("handshake rejected")
+	// ErrHandshakeFailed : handshake 過程中的通訊錯誤
+	ErrHandshakeFailed = errors.New("handshake failed")
+)
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+// handshake : client 端，送出 HandshakeData 並等待回應
+// @param	data	要送出的 handshake，不會被修改
+func (c *Connector) handshake(conn *websocket.Conn, data *HandshakeData) (*HandshakeAckData, error) {
+	req := *data
+	if req.Version == 0 {
+		req.Version = ProtocolVersion
+	}
+	if req.MinVersion == 0 {
+		req.MinVersion = MinProtocolVersion
+	}
+	req.Features = c.config.features()
+	if c.config.Auth != nil {
+		c.config.Auth.sign(&req)
+	}
+	timeout := c.config.handshakeTimeout()
+	if err := writeHandshake(conn, timeout, CommandHandshake, &req); err != nil {
+		return nil, err
+	}
+	ack := &HandshakeAckData{}
+	if err := readHandshake(conn, timeout, CommandHandshakeAck, ack); err != nil {
+		return nil, err
+	}
+	if !ack.Accepted {
+		return nil, fmt.Errorf("%w: %s", ErrHandshakeRejected, ack.Reason)
+	}
+	if ack.Version < req.MinVersion || req.Version < ack.Version {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrHandshakeFailed, ack.Version)
+	}
+	return ack, nil
+}
+
+// readHandshake : 讀取指定型別的 handshake 命令
+func readHandshake(conn *websocket.Conn, timeout time.Duration, inType uint32, in proto.Message) error {
+	_, cmd, err := readFirst(conn, timeout)
+	if err != nil {
+		return err
+	}
+	return parseHandshake(cmd, inType, in)
+}
+
+// readFirst : 讀取連線的第一個封包
+// @return	原始資料 & Command & error
+func readFirst(conn *websocket.Conn, timeout time.Duration) ([]byte, *Command, error) {
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	defer conn.SetReadDeadline(time.Time{})
+	mt, msg, err := conn.ReadMessage()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", ErrHandshakeFailed, err.Error())
+	}
+	if mt != websocket.BinaryMessage {
+		return nil, nil, fmt.Errorf("%w: unknown message type %d", ErrHandshakeFailed, mt)
+	}
+	cmd, err := CreateCommand(msg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", ErrHandshakeFailed, err.Error())
+	}
+	return msg, cmd, nil
+}
+
+// parseHandshake : 檢查型別並解出 handshake 資料
+func parseHandshake(cmd *Command, inType uint32, in proto.Message) error {
+	if cmd.Type() != inType {
+		return fmt.Errorf("%w: unexpected command %#x", ErrHandshakeFailed, cmd.Type())
+	}
+	if err := proto.Unmarshal(cmd.Data(), in); err != nil {
+		return fmt.Errorf("%w: %s", ErrHandshakeFailed, err.Error())
+	}
+	return nil
+}
+
+func writeHandshake(conn *websocket.Conn, timeout time.Duration, cmdType uint32, pb proto.Message) error {
+	cmd := NewCommand(cmdType, pb)
+	if cmd == nil {
+		return fmt.Errorf("%w: invalid data", ErrHandshakeFailed)
+	}
+	conn.SetWriteDeadline(time.Now().Add(timeout))
+	defer conn.SetWriteDeadline(time.Time{})
+	if err := conn.WriteMessage(websocket.BinaryMessage, cmd.Bytes()); err != nil {
+		return fmt.Errorf("%w: %s", ErrHandshakeFailed, err.Error())
+	}
+	return nil
+}
+
+// negotiate : server 端，決定協定版本與命令集
+// @param	req		client 的 HandshakeData
+// @param	version	server 支援的最高版本
+// @param	minVersion	server 支援的最低版本
+// @param	sets	server 支援的命令集，nil 則接受 client 所有的
+// @param	features	server 啟用的傳輸功能
+func negotiate(req *HandshakeData, version, minVersion uint32, sets, features []string) (*HandshakeAckData, error) {
+	high := req.Version
+	if version < high {
+		high = version
+	}
+	low := req.MinVersion
+	if low < minVersion {
+		low = minVersion
+	}
+	if high < low || high == 0 {
+		return nil, fmt.Errorf("no common protocol version. CLIENT=%d~%d, SERVER=%d~%d", req.MinVersion, req.Version, minVersion, version)
+	}
+	ack := &HandshakeAckData{Accepted: true, Version: high}
+	for _, want := range req.Features {
+		for _, have := range features {
+			if want == have {
+				ack.Features = append(ack.Features, want)
+				break
+			}
+		}
+	}
+	if sets == nil {
+		ack.CommandSets = req.CommandSets
+		return ack, nil
+	}
+	for _, want := range req.CommandSets {
+		for _, have := range sets {
+			if want == have {
+				ack.CommandSets = append(ack.CommandSets, want)
+				break
+			}
+		}
+	}
+	return ack, nil
+}
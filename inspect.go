@@ -0,0 +1,174 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"sort"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//	Constants
+//------------------------------------------------------------------------------
+
+const (
+	// QueueHead.Reason
+	queueReasonQueued     = "queued"
+	queueReasonDelayed    = "delayed"
+	queueReasonDependency = "dependency"
+)
+
+//------------------------------------------------------------------------------
+//	Structure declare
+//------------------------------------------------------------------------------
+
+type (
+	// TaskSnapshot : Task 狀態快照，admin 查詢用
+	TaskSnapshot struct {
+		ID         uint64      // Task 流水號
+		Name       string      // handler 名稱
+		State      string      // 目前狀態
+		SubmitTime time.Time   // 送出時間
+		Waiting    int         `json:",omitempty"` // 尚未完成的前置 Task 數量
+		Tags       []string    `json:",omitempty"` // 排隊中的 OrderData 標籤
+		Queues     []QueueHead `json:",omitempty"` // 各 OrderData 的排隊狀況
+	}
+
+	// QueueHead : Task 在某個 OrderData 中的排隊狀況
+	QueueHead struct {
+		Tag      string // OrderData 標籤
+		Position int    // 在佇列中的位置，0 表示排在最前面；不在佇列中時為 -1，原因見 Reason
+		Reason   string // "queued": 在佇列中，"delayed": 延遲中，"dependency": 等待前置 Task 完成後才進入佇列
+		Length   int    // 佇列長度
+		HeadID   uint64 `json:",omitempty"` // 排在最前面的 Task 流水號
+		HeadName string `json:",omitempty"` // 排在最前面的 Task handler 名稱
+	}
+
+	// OrderQueueData : OrderData 佇列內容
+	OrderQueueData struct {
+		Tag    string         // OrderData 標籤
+		Works  []TaskSnapshot // 依序排隊中的 Task，第一個為正在執行或即將執行者
+		Delays []TaskSnapshot // 延遲中尚未進入佇列的 Task
+	}
+)
+
+//------------------------------------------------------------------------------
+//	Public Methods
+//------------------------------------------------------------------------------
+
+// GetBlockedTasks : 取得目前被阻擋中的 Task，依流水號排序
+func (p *poolManager) GetBlockedTasks() []TaskSnapshot {
+	works := p.blockWorks.ToSlice()
+	res := make([]TaskSnapshot, len(works))
+	for i, work := range works {
+		res[i] = work.(*Task).Snapshot()
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res
+}
+
+// Snapshot : 取得 Task 目前狀態與排隊狀況
+func (w *Task) Snapshot() TaskSnapshot {
+	res := w.brief()
+	if w.checker == nil {
+		return res
+	}
+	orders := w.checker.orders()
+	res.Tags = make([]string, len(orders))
+	res.Queues = make([]QueueHead, len(orders))
+	for i, order := range orders {
+		res.Tags[i] = order.tag
+		res.Queues[i] = order.queueHead(w, res.Waiting > 0)
+	}
+	return res
+}
+
+// Tag : 取得 OrderInit 時所給的標籤
+func (o *OrderData) Tag() string {
+	return o.tag
+}
+
+// DumpQueue : 取得目前排隊與延遲中的 Task
+func (o *OrderData) DumpQueue() OrderQueueData {
+	works := o.works.ToSlice()
+	delays := o.delays.ToSlice()
+	res := OrderQueueData{
+		Tag:    o.tag,
+		Works:  make([]TaskSnapshot, len(works)),
+		Delays: make([]TaskSnapshot, len(delays)),
+	}
+	for i, work := range works {
+		res.Works[i] = work.(*Task).brief()
+	}
+	for i, work := range delays {
+		res.Delays[i] = work.(*Task).brief()
+	}
+	sort.Slice(res.Delays, func(i, j int) bool { return res.Delays[i].ID < res.Delays[j].ID })
+	return res
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+// brief : 不含排隊狀況的快照
+func (w *Task) brief() TaskSnapshot {
+	w.Lock()
+	defer w.Unlock()
+	return TaskSnapshot{
+		ID:         w.id,
+		Name:       w.name,
+		State:      w.state.String(),
+		SubmitTime: w.submitAt,
+		Waiting:    w.waiting,
+	}
+}
+
+// queueHead : Task 在此佇列中的狀況
+// @param	waiting	Task 是否還在等待前置 Task
+func (o *OrderData) queueHead(work *Task, waiting bool) QueueHead {
+	works := o.works.ToSlice()
+	res := QueueHead{
+		Tag:      o.tag,
+		Position: -1,
+		Length:   len(works),
+	}
+	if waiting {
+		res.Reason = queueReasonDependency
+	}
+	if len(works) > 0 {
+		head := works[0].(*Task)
+		res.HeadID = head.id
+		res.HeadName = head.name
+	}
+	for i, item := range works {
+		if item.(*Task) == work {
+			res.Position = i
+			res.Reason = queueReasonQueued
+			return res
+		}
+	}
+	if o.delays.Contains(work) {
+		res.Reason = queueReasonDelayed
+	}
+	return res
+}
@@ -0,0 +1,79 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"testing"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//	Structure declare
+//------------------------------------------------------------------------------
+
+type (
+	// inspectTestPlayer : 測試用的排隊物件
+	inspectTestPlayer struct {
+		OrderData
+	}
+)
+
+//------------------------------------------------------------------------------
+//	Tests
+//------------------------------------------------------------------------------
+
+func TestSnapshotQueueReason(t *testing.T) {
+	startTestPool()
+	player := &inspectTestPlayer{}
+	player.OrderInit("player")
+	other := &inspectTestPlayer{}
+	other.OrderInit("other")
+
+	gate := make(chan struct{})
+	defer close(gate)
+	running := PoolManager.SendWork(func(gate chan struct{}) { <-gate }, gate, MakeBarrier(player))
+	queued := PoolManager.SendWork(func() {}, MakeBarrier(player))
+	dependent := PoolManager.SendWorkAfter([]*Task{running}, func() {}, MakeBarrier(player))
+	delayed := PoolManager.SendWork(func() {}, DelayBarrier(time.Hour, other))
+	defer delayed.Cancel()
+
+	for _, tc := range []struct {
+		name     string
+		task     *Task
+		position int
+		reason   string
+	}{
+		{"running", running, 0, queueReasonQueued},
+		{"queued", queued, 1, queueReasonQueued},
+		{"dependent", dependent, -1, queueReasonDependency},
+		{"delayed", delayed, -1, queueReasonDelayed},
+	} {
+		snapshot := tc.task.Snapshot()
+		if len(snapshot.Queues) != 1 {
+			t.Fatalf("%s: unexpected queue count %d", tc.name, len(snapshot.Queues))
+		}
+		head := snapshot.Queues[0]
+		if head.Position != tc.position || head.Reason != tc.reason {
+			t.Errorf("%s: unexpected queue head. POSITION=%d, REASON=%s", tc.name, head.Position, head.Reason)
+		}
+	}
+}
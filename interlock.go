@@ -136,6 +136,11 @@ func (i *InterlockInt64) Decrement() int64 {
 	return atomic.AddInt64((*int64)(i), -1)
 }
 
+// Add : add delta to InterlockInt64 value
+func (i *InterlockInt64) Add(delta int64) int64 {
+	return atomic.AddInt64((*int64)(i), delta)
+}
+
 // Exchange : 交換資料，將會回傳舊值
 func (i *InterlockInt64) Exchange(new int64) int64 {
 	return atomic.SwapInt64((*int64)(i), new)
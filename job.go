@@ -41,31 +41,49 @@ const (
 	JobStateCancel
 )
 
+var (
+	jobEnumStringMap = map[JobStateEnum]string{
+		JobStateIdle:    "JobStateIdle",
+		JobStateRun:     "JobStateRun",
+		JobStateSuspend: "JobStateSuspend",
+		JobStateCancel:  "JobStateCancel",
+	}
+)
+
 //------------------------------------------------------------------------------
 //	Structure declare
 //------------------------------------------------------------------------------
 
 // Job 獨立工作
 type Job struct {
+	id         int64
 	handler    reflect.Value
 	name       string
 	elems      []reflect.Value
 	interval   time.Duration
 	state      JobStateEnum
-	resumed    chan bool
+	resumed    chan bool     // Resume 喚醒暫停中的工作，長度 1，不會阻塞呼叫者
+	cancelled  chan struct{} // Cancel 時關閉，喚醒等待中的工作
 	waitGroup  *sync.WaitGroup
 	delayStart time.Duration
+	lock       sync.Mutex
 }
 
 //------------------------------------------------------------------------------
 //	Public Methods
 //------------------------------------------------------------------------------
 
+func (s JobStateEnum) String() string {
+	return jobEnumStringMap[s]
+}
+
 // Run : 開始執行工作 (必須呼叫！)
 // @param	delay	延遲多久後開始工作 time.Duration 格式，可不輸入
 func (j *Job) Run(delay ...interface{}) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
 	if j.state != JobStateIdle {
-		Error("Job:Run: invalid state. FUNC=%s, STATE=%d", j.name, j.state)
+		logger(poolLog).Error("Job:Run: invalid state. FUNC=%s, STATE=%d", j.name, j.state)
 		return
 	}
 	j.waitGroup.Add(1)
@@ -79,9 +97,11 @@ func (j *Job) Run(delay ...interface{}) {
 	go j.jobProcess()
 }
 
-// Suspend : 暫停執行工作
+// Suspend : 暫停執行工作，正在執行的 handler 結束後才會暫停
 func (j *Job) Suspend() {
-	if j.state == JobStateSuspend {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	if j.state != JobStateRun {
 		return
 	}
 	j.state = JobStateSuspend
@@ -89,48 +109,96 @@ func (j *Job) Suspend() {
 
 // Resume : 恢復執行工作
 func (j *Job) Resume() {
+	j.lock.Lock()
+	defer j.lock.Unlock()
 	if j.state != JobStateSuspend {
 		return
 	}
 	j.state = JobStateRun
-	j.resumed <- true
+	select {
+	case j.resumed <- true:
+	default:
+	}
 }
 
-// Cancel : 結束工作
+// Cancel : 結束工作，暫停或等待中的工作也會結束
 func (j *Job) Cancel() {
+	j.lock.Lock()
+	prev := j.state
+	if prev == JobStateCancel {
+		j.lock.Unlock()
+		return
+	}
 	j.state = JobStateCancel
+	close(j.cancelled)
+	j.lock.Unlock()
+	// 尚未 Run 的工作不會進入 jobProcess，直接移除
+	if prev == JobStateIdle {
+		PoolManager.removeJob(j)
+	}
 }
 
 // GetStatus : 取得目前工作狀態
 func (j *Job) GetStatus() JobStateEnum {
+	j.lock.Lock()
+	defer j.lock.Unlock()
 	return j.state
 }
 
+// ID : 取得工作編號
+func (j *Job) ID() int64 {
+	return j.id
+}
+
+// Name : 取得工作的 function 名稱
+func (j *Job) Name() string {
+	return j.name
+}
+
+// Interval : 取得執行週期
+func (j *Job) Interval() time.Duration {
+	return j.interval
+}
+
 //------------------------------------------------------------------------------
 // Private Methods
 //------------------------------------------------------------------------------
 
 func (j *Job) jobProcess() {
 	if j.delayStart > 0 {
-		<-time.After(j.delayStart)
+		j.wait(j.delayStart)
 	}
 	for {
-		switch j.state {
+		switch j.GetStatus() {
 		case JobStateRun:
 			j.handler.Call(j.elems)
 
 		case JobStateSuspend:
-			<-j.resumed
+			select {
+			case <-j.resumed:
+			case <-j.cancelled:
+			}
+			continue
 
 		case JobStateCancel:
 			j.waitGroup.Done()
 			PoolManager.removeJob(j)
-			Info("Job:process: job end. NAME=%s", j.name)
+			logger(poolLog).Info("Job:process: job end. NAME=%s", j.name)
 			return
 		}
 		// call for delay.
 		if j.interval > 0 {
-			<-time.After(j.interval)
+			j.wait(j.interval)
 		}
 	}
 }
+
+// wait : 等待 d 或被 Cancel
+func (j *Job) wait(d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-j.cancelled:
+	}
+}
@@ -85,15 +85,12 @@ var (
 		"ERROR":  2,
 		"CRIT":   1,
 	}
-	logLevel    = 6
+	logLevel    = NewInterlockInt32(debug)
 	timeFormat  = "2006-01-02 15:04:05"
 	mutex       = &sync.RWMutex{}
 	outFilename = ""
-	fileOpened  = false
-	outFile     = os.NewFile(0, "")
 	outDetail   = false
 	colorable   = true
-	logStrings  = NewConcurrentArray()
 	colorWriter = ansicolor.NewAnsiColorWriter(os.Stdout)
 	_, b, _, _  = runtime.Caller(0)
 	basepath    = filepath.Dir(b) + "\\.."
@@ -103,13 +100,33 @@ var (
 //	Structure declare
 //------------------------------------------------------------------------------
 
+// LogLevel : log 等級，數字越小越嚴重
+type LogLevel int
+
+const (
+	// LogLevelCritical : 嚴重錯誤
+	LogLevelCritical LogLevel = critical
+	// LogLevelError : 錯誤
+	LogLevelError LogLevel = err
+	// LogLevelWarn : 警告
+	LogLevelWarn LogLevel = warn
+	// LogLevelNotice : 注意
+	LogLevelNotice LogLevel = notice
+	// LogLevelInfo : 一般訊息
+	LogLevelInfo LogLevel = info
+	// LogLevelDebug : 除錯訊息
+	LogLevelDebug LogLevel = debug
+)
+
 type (
-	logMessage struct {
-		format string
-		v      []interface{}
-		file   string
-		line   int
-		level  int
+	// LogRecord : 一筆 log 紀錄
+	LogRecord struct {
+		Time    time.Time     // 紀錄時間
+		Level   LogLevel      // 等級
+		File    string        // 呼叫者所在檔案
+		Line    int           // 呼叫者所在行號
+		Message string        // 已經格式化的訊息
+		Fields  []interface{} // key, value 交錯的結構化欄位
 	}
 )
 
@@ -120,55 +137,69 @@ type (
 // LoggerSetup : 設定 log 的一些基礎設定
 // @param	hasDetail	是否印出所在檔案與行號
 // @param	hasFile		是否將紀錄寫入檔案（自動）
-// @param	logLevel	記錄等級
+// @param	logLevel	記錄等級，有設定 AGENCY_LOG 時以環境變數為準
 func LoggerSetup(hasDetail, hasFile bool, logLevel string) {
 	outDetail = hasDetail
 	if hasFile {
 		OpenLogFile()
 	}
 	SetLogLevel(logLevel)
+	// 格式錯誤已在 init 時提示
+	applyLogLevelEnv()
 }
 
 func Debug(format string, v ...interface{}) {
-	if logLevel == debug {
-		writeLog(createMessage(format, v, 6))
+	if levelEnabled(debug) {
+		writeLog(createMessage(format, v, nil, 6))
 	}
 }
 
 func Info(format string, v ...interface{}) {
-	if logLevel >= info {
-		writeLog(createMessage(format, v, 5))
+	if levelEnabled(info) {
+		writeLog(createMessage(format, v, nil, 5))
 	}
 }
 
 func Notice(format string, v ...interface{}) {
-	if logLevel >= notice {
-		writeLog(createMessage(format, v, 4))
+	if levelEnabled(notice) {
+		writeLog(createMessage(format, v, nil, 4))
 	}
 }
 
 func Warn(format string, v ...interface{}) {
-	if logLevel >= warn {
-		writeLog(createMessage(format, v, 3))
+	if levelEnabled(warn) {
+		writeLog(createMessage(format, v, nil, 3))
 	}
 }
 
 func Error(format string, v ...interface{}) {
-	if logLevel >= err {
-		writeLog(createMessage(format, v, 2))
+	if levelEnabled(err) {
+		writeLog(createMessage(format, v, nil, 2))
 	}
 }
 
 func Critical(format string, v ...interface{}) {
-	if logLevel >= critical {
-		writeLog(createMessage(format, v, 1))
+	if levelEnabled(critical) {
+		writeLog(createMessage(format, v, nil, 1))
 	}
 }
 
+// ParseLogLevel : 將 "DEBUG", "INFO", "NOTICE", "WARN", "ERROR", "CRIT" 轉為 LogLevel
+func ParseLogLevel(level string) (LogLevel, error) {
+	if numLevel, ok := logLevels[strings.ToUpper(level)]; ok {
+		return LogLevel(numLevel), nil
+	}
+	return 0, errors.New("Invalid log level: " + level)
+}
+
+func (l LogLevel) String() string {
+	return levelNames[int(l)]
+}
+
 func SetLogLevel(level string) error {
 	level = strings.ToUpper(level)
 	if numLevel, ok := logLevels[level]; ok {
-		logLevel = numLevel
+		logLevel.Exchange(int32(numLevel))
 		return nil
 	}
 	return errors.New("Invalid log level: " + level)
@@ -183,7 +214,9 @@ func OpenLogFile() {
 }
 
 func CloseLogFile() {
-	outFile.Close()
+	if err := RemoveLogSink(fileSinkName); err != nil {
+		fmt.Println("log:CloseLogFile: not opened.")
+	}
 }
 
 func GetAppName() string {
@@ -194,36 +227,28 @@ func GetAppName() string {
 	return strings.Split(strs[len(strs)-1], ".")[0]
 }
 
+// GetLogContents : 分頁取得記憶體中的 log
+// @param	index	起始流水號，-1 則取最後一頁；已被淘汰時從保留的第一行開始
+// @return	log 內容 & 此頁第一行的流水號
 func GetLogContents(index int) ([]string, int) {
-	var gain []interface{}
-	pos := 0
-	if index == -1 {
-		lines := logStrings.Len()
-		if lines < onceFetchLines {
-			pos = 0
-		} else {
-			pos = lines - onceFetchLines - 1
-		}
-	} else {
-		pos = index
+	entries, next := QueryLogs(LogQuery{From: index})
+	res := make([]string, len(entries))
+	for i, entry := range entries {
+		res[i] = entry.Line
 	}
-	gain = logStrings.GainFrom(pos, onceFetchLines)
-
-	length := len(gain)
-	res := make([]string, length)
-	for i := 0; i < length; i++ {
-		res[i] = gain[i].(string)
-	}
-	return res, pos
+	return res, next - len(entries)
 }
 
 //------------------------------------------------------------------------------
 //	Private Methods
 //------------------------------------------------------------------------------
 
-func createMessage(format string, v []interface{}, level int) *logMessage {
+func createMessage(format string, v []interface{}, fields []interface{}, level int) *LogRecord {
 	file, line := getFileAndLine()
-	return &logMessage{format, v, file, line, level}
+	if len(v) > 0 {
+		format = fmt.Sprintf(format, v...)
+	}
+	return &LogRecord{time.Now(), LogLevel(level), file, line, format, fields}
 }
 
 func getFileAndLine() (string, int) {
@@ -232,20 +257,36 @@ func getFileAndLine() (string, int) {
 	return rel, line
 }
 
-func writeLog(data *logMessage) {
+func writeLog(data *LogRecord) {
+	ok, summary := logLimits.allow(data)
+	if summary != nil {
+		writeRecord(summary)
+	}
+	if ok {
+		writeRecord(data)
+	}
+}
+
+func writeRecord(data *LogRecord) {
+	if writeAsync(data) {
+		return
+	}
+	writeSinks([]*LogRecord{data})
+}
+
+func writeSinks(records []*LogRecord) {
 	mutex.Lock()
 	defer mutex.Unlock()
-	cout, fout := createLogString(data)
-	fmt.Fprintln(colorWriter, cout)
-	if fileOpened {
-		outFile.WriteString(fout + "\n")
+	for _, data := range records {
+		for _, entry := range logSinks {
+			entry.write(data)
+		}
 	}
-	logStrings.Append(fout)
 }
 
-func createLogString(data *logMessage) (string, string) {
+func createLogString(data *LogRecord, colored bool) string {
 	var color, level string
-	switch data.level {
+	switch data.Level {
 	case debug:
 		color = fgCyan
 		level = "[D]"
@@ -265,41 +306,38 @@ func createLogString(data *logMessage) (string, string) {
 		color = fbBoldRed
 		level = "[C]"
 	}
-	now := time.Now().Format(timeFormat)
+	now := data.Time.Format(timeFormat)
 	out := ""
 	if outDetail {
-		out = fmt.Sprint(level, " ", now, " ", data.file, ":", data.line, "  ▶  ")
+		out = fmt.Sprint(level, " ", now, " ", data.File, ":", data.Line, "  ▶  ")
 	} else {
 		out = fmt.Sprint(level, " ", now, "  ▶  ")
 	}
-
-	if len(data.v) > 0 {
-		out = out + fmt.Sprintf(data.format, data.v...)
-	} else {
-		out = out + data.format
+	out = out + data.Message
+	if len(data.Fields) > 0 {
+		out = out + renderFields(data.Fields)
 	}
-	if data.level != info && colorable {
-		return fmt.Sprint(color, out, fgNormal), out
+	if data.Level != info && colored {
+		return fmt.Sprint(color, out, fgNormal)
 	}
-	return out, out
+	return out
 }
 
 func createLogFile() {
-	mutex.Lock()
-	defer mutex.Unlock()
-	if fileOpened {
-		fmt.Println("log:createLogFile: already exist.")
-		return
-	}
-	if _, err := os.Stat(outFilename); os.IsNotExist(err) {
-		outFile, err = os.Create(outFilename)
-		if err != nil {
+	file, err := os.OpenFile(outFilename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			fmt.Println("log:createLogFile: file exist. FILE=", outFilename)
+		} else {
 			fmt.Println("log:createLogFile: cannot create log file. FILE=", outFilename)
-			return
 		}
-		fileOpened = true
+		return
+	}
+	sink := NewWriterSink(file)
+	if err = AddLogSink(fileSinkName, sink, SinkConfig{Formatter: defaultFormatter(false)}); err != nil {
+		fmt.Println("log:createLogFile: already exist.")
+		file.Close()
 	}
-	fmt.Println("log:createLogFile: file exist. FILE=", outFilename)
 }
 
 //------------------------------------------------------------------------------
@@ -309,4 +347,6 @@ func createLogFile() {
 func init() {
 	now := time.Now().Format("20060102-150405")
 	outFilename = fmt.Sprintf("%s_%s.txt", GetAppName(), now)
+	AddLogSink(consoleSinkName, NewWriterSink(colorWriter), SinkConfig{Formatter: defaultFormatter(true)})
+	AddLogSink(historySinkName, historySink{}, SinkConfig{Formatter: ConsoleFormatter{}})
 }
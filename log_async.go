@@ -0,0 +1,242 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+// Enumeration
+//------------------------------------------------------------------------------
+
+// LogOverflow : 非同步 log 緩衝區滿時的處理方式
+type LogOverflow int
+
+const (
+	// LogOverflowBlock : 等待緩衝區有空間
+	LogOverflowBlock LogOverflow = iota
+	// LogOverflowDrop : 丟棄，並定期輸出丟棄數量
+	LogOverflowDrop
+	// LogOverflowSample : 每 SampleRate 筆保留一筆，其餘丟棄
+	LogOverflowSample
+)
+
+//------------------------------------------------------------------------------
+//	Constants
+//------------------------------------------------------------------------------
+
+const (
+	defaultAsyncBufferSize = 4096
+	defaultAsyncBatchSize  = 128
+	defaultAsyncSampleRate = 10
+)
+
+//------------------------------------------------------------------------------
+//	Variables
+//------------------------------------------------------------------------------
+
+var (
+	asyncLog  *asyncLogger
+	asyncLock sync.RWMutex
+)
+
+//------------------------------------------------------------------------------
+//	Structure declare
+//------------------------------------------------------------------------------
+
+type (
+	// AsyncLogConfig : 非同步 log 設定
+	AsyncLogConfig struct {
+		BufferSize int         // 緩衝區大小，0 則為 4096
+		BatchSize  int         // 一次寫入的最大筆數，0 則為 128
+		Overflow   LogOverflow // 緩衝區滿時的處理方式，ERROR 以上的等級一律等待
+		SampleRate int         // LogOverflowSample 時每幾筆保留一筆，0 則為 10
+	}
+
+	asyncLogger struct {
+		config  AsyncLogConfig
+		queue   chan *asyncItem
+		done    chan struct{}
+		dropped uint64
+		sampled uint64
+	}
+
+	// asyncItem : record 與 flush 二擇一
+	asyncItem struct {
+		record *LogRecord
+		flush  chan struct{}
+	}
+)
+
+//------------------------------------------------------------------------------
+//	Public Methods
+//------------------------------------------------------------------------------
+
+// EnableAsyncLog : 改為由背景 goroutine 寫出 log，呼叫端不再等待 sink
+// @param	config	非同步設定
+func EnableAsyncLog(config AsyncLogConfig) error {
+	if config.BufferSize < 0 || config.BatchSize < 0 || config.SampleRate < 0 {
+		return errors.New("invalid async log config")
+	}
+	if config.BufferSize == 0 {
+		config.BufferSize = defaultAsyncBufferSize
+	}
+	if config.BatchSize == 0 {
+		config.BatchSize = defaultAsyncBatchSize
+	}
+	if config.SampleRate == 0 {
+		config.SampleRate = defaultAsyncSampleRate
+	}
+	asyncLock.Lock()
+	defer asyncLock.Unlock()
+	if asyncLog != nil {
+		return errors.New("async log already enabled")
+	}
+	asyncLog = &asyncLogger{
+		config: config,
+		queue:  make(chan *asyncItem, config.BufferSize),
+		done:   make(chan struct{}),
+	}
+	go asyncLog.process()
+	return nil
+}
+
+// FlushLog : 等待目前已送出的 log 全部寫出，非非同步模式時直接返回
+func FlushLog() {
+	asyncLock.RLock()
+	a := asyncLog
+	if a == nil {
+		asyncLock.RUnlock()
+		return
+	}
+	flush := make(chan struct{})
+	a.queue <- &asyncItem{flush: flush}
+	asyncLock.RUnlock()
+	<-flush
+}
+
+// CloseLog : 寫出所有的 log 並恢復為同步模式，最後關閉 log 檔。結束程式前呼叫
+func CloseLog() {
+	asyncLock.Lock()
+	a := asyncLog
+	asyncLog = nil
+	asyncLock.Unlock()
+	if a != nil {
+		close(a.queue)
+		<-a.done
+	}
+	RemoveLogSink(fileSinkName)
+}
+
+// GetDroppedLogs : 非同步模式下因緩衝區滿而丟棄的 log 數量
+func GetDroppedLogs() uint64 {
+	asyncLock.RLock()
+	defer asyncLock.RUnlock()
+	if asyncLog == nil {
+		return 0
+	}
+	return atomic.LoadUint64(&asyncLog.dropped)
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+// writeAsync : 非同步模式時放入緩衝區
+// @return	是否已處理
+func writeAsync(data *LogRecord) bool {
+	asyncLock.RLock()
+	defer asyncLock.RUnlock()
+	if asyncLog == nil {
+		return false
+	}
+	asyncLog.push(data)
+	return true
+}
+
+func (a *asyncLogger) push(data *LogRecord) {
+	item := &asyncItem{record: data}
+	if a.config.Overflow == LogOverflowBlock || data.Level <= err {
+		a.queue <- item
+		return
+	}
+	select {
+	case a.queue <- item:
+		return
+	default:
+	}
+	if a.config.Overflow == LogOverflowSample &&
+		atomic.AddUint64(&a.sampled, 1)%uint64(a.config.SampleRate) == 0 {
+		a.queue <- item
+		return
+	}
+	atomic.AddUint64(&a.dropped, 1)
+}
+
+func (a *asyncLogger) process() {
+	defer close(a.done)
+	batch := make([]*LogRecord, 0, a.config.BatchSize)
+	var reported uint64
+	for item := range a.queue {
+		var flushes []chan struct{}
+		// 盡量一次取出多筆，減少鎖定次數
+		for {
+			if item.flush != nil {
+				flushes = append(flushes, item.flush)
+			} else {
+				batch = append(batch, item.record)
+			}
+			if len(batch) >= a.config.BatchSize {
+				break
+			}
+			var ok bool
+			select {
+			case item, ok = <-a.queue:
+			default:
+			}
+			if !ok {
+				break
+			}
+		}
+		if dropped := atomic.LoadUint64(&a.dropped); dropped != reported {
+			batch = append(batch, &LogRecord{
+				Time:    time.Now(),
+				Level:   warn,
+				Message: fmt.Sprintf("log:async: buffer full, dropped logs. COUNT=%d", dropped-reported),
+			})
+			reported = dropped
+		}
+		writeSinks(batch)
+		for i := range batch {
+			batch[i] = nil
+		}
+		batch = batch[:0]
+		for _, flush := range flushes {
+			close(flush)
+		}
+	}
+}
@@ -0,0 +1,342 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+// Enumeration
+//------------------------------------------------------------------------------
+
+// LogFormat : log 輸出格式
+type LogFormat int
+
+const (
+	// LogFormatConsole : 原本的文字格式，console 有顏色
+	LogFormatConsole LogFormat = iota
+	// LogFormatJSON : 一行一個 JSON 物件
+	LogFormatJSON
+)
+
+//------------------------------------------------------------------------------
+//	Constants
+//------------------------------------------------------------------------------
+
+const (
+	// 欄位數量為奇數時，最後一個值使用的鍵值
+	badFieldKey = "!BADKEY"
+)
+
+//------------------------------------------------------------------------------
+//	Variables
+//------------------------------------------------------------------------------
+
+var (
+	logFormat = LogFormatConsole
+
+	levelNames = map[int]string{
+		debug:    "DEBUG",
+		info:     "INFO",
+		notice:   "NOTICE",
+		warn:     "WARN",
+		err:      "ERROR",
+		critical: "CRIT",
+	}
+)
+
+//------------------------------------------------------------------------------
+//	Structure declare
+//------------------------------------------------------------------------------
+
+type (
+	// FieldLogger : 帶有固定結構化欄位的 logger，ex:
+	//	l := agency.With("service", "vip", "account", id)
+	//	l.Info("login. LEN=%d", n)
+	//	l.InfoKV("connector command", "cmd", t, "len", n)
+	FieldLogger struct {
+		fields []interface{}
+		module *logModule // 由 ModuleLogger 建立時使用該模組的等級
+	}
+)
+
+//------------------------------------------------------------------------------
+//	Public Methods
+//------------------------------------------------------------------------------
+
+// SetLogFormat : 設定 console 與 log 檔的輸出格式，記憶體中的 log 維持文字格式
+// @param	format	LogFormatConsole or LogFormatJSON
+func SetLogFormat(format LogFormat) error {
+	switch format {
+	case LogFormatConsole, LogFormatJSON:
+		mutex.Lock()
+		defer mutex.Unlock()
+		logFormat = format
+		for _, entry := range logSinks {
+			switch entry.name {
+			case consoleSinkName:
+				entry.formatter = defaultFormatter(true)
+			case fileSinkName:
+				entry.formatter = defaultFormatter(false)
+			}
+		}
+		return nil
+	}
+	return errors.New("Invalid log format: " + strconv.Itoa(int(format)))
+}
+
+// With : 建立帶有結構化欄位的 logger
+// @param	kv	key, value 交錯的欄位
+func With(kv ...interface{}) *FieldLogger {
+	return &FieldLogger{fields: copyFields(nil, kv)}
+}
+
+// WithContext : 建立帶有 ctx 中 trace_id、span_id 欄位的 logger，ctx 沒有 trace 時不加欄位
+func WithContext(ctx context.Context) *FieldLogger {
+	return (&FieldLogger{}).WithContext(ctx)
+}
+
+func DebugKV(msg string, kv ...interface{}) {
+	if levelEnabled(debug) {
+		writeLog(createMessage(msg, nil, copyFields(nil, kv), debug))
+	}
+}
+
+func InfoKV(msg string, kv ...interface{}) {
+	if levelEnabled(info) {
+		writeLog(createMessage(msg, nil, copyFields(nil, kv), info))
+	}
+}
+
+func NoticeKV(msg string, kv ...interface{}) {
+	if levelEnabled(notice) {
+		writeLog(createMessage(msg, nil, copyFields(nil, kv), notice))
+	}
+}
+
+func WarnKV(msg string, kv ...interface{}) {
+	if levelEnabled(warn) {
+		writeLog(createMessage(msg, nil, copyFields(nil, kv), warn))
+	}
+}
+
+func ErrorKV(msg string, kv ...interface{}) {
+	if levelEnabled(err) {
+		writeLog(createMessage(msg, nil, copyFields(nil, kv), err))
+	}
+}
+
+func CriticalKV(msg string, kv ...interface{}) {
+	if levelEnabled(critical) {
+		writeLog(createMessage(msg, nil, copyFields(nil, kv), critical))
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// With : 建立一個多了指定欄位的子 logger
+// @param	kv	key, value 交錯的欄位
+func (l *FieldLogger) With(kv ...interface{}) *FieldLogger {
+	return &FieldLogger{fields: copyFields(l.fields, kv), module: l.module}
+}
+
+// WithContext : 建立一個多了 ctx 中 trace_id、span_id 欄位的子 logger
+func (l *FieldLogger) WithContext(ctx context.Context) *FieldLogger {
+	tc, ok := TraceFromContext(ctx)
+	if !ok {
+		return l
+	}
+	return &FieldLogger{fields: traceFields(l.fields, tc), module: l.module}
+}
+
+func (l *FieldLogger) Debug(format string, v ...interface{}) {
+	if l.enabled(debug) {
+		writeLog(createMessage(format, v, l.fields, debug))
+	}
+}
+
+func (l *FieldLogger) Info(format string, v ...interface{}) {
+	if l.enabled(info) {
+		writeLog(createMessage(format, v, l.fields, info))
+	}
+}
+
+func (l *FieldLogger) Notice(format string, v ...interface{}) {
+	if l.enabled(notice) {
+		writeLog(createMessage(format, v, l.fields, notice))
+	}
+}
+
+func (l *FieldLogger) Warn(format string, v ...interface{}) {
+	if l.enabled(warn) {
+		writeLog(createMessage(format, v, l.fields, warn))
+	}
+}
+
+func (l *FieldLogger) Error(format string, v ...interface{}) {
+	if l.enabled(err) {
+		writeLog(createMessage(format, v, l.fields, err))
+	}
+}
+
+func (l *FieldLogger) Critical(format string, v ...interface{}) {
+	if l.enabled(critical) {
+		writeLog(createMessage(format, v, l.fields, critical))
+	}
+}
+
+func (l *FieldLogger) DebugKV(msg string, kv ...interface{}) {
+	if l.enabled(debug) {
+		writeLog(createMessage(msg, nil, copyFields(l.fields, kv), debug))
+	}
+}
+
+func (l *FieldLogger) InfoKV(msg string, kv ...interface{}) {
+	if l.enabled(info) {
+		writeLog(createMessage(msg, nil, copyFields(l.fields, kv), info))
+	}
+}
+
+func (l *FieldLogger) NoticeKV(msg string, kv ...interface{}) {
+	if l.enabled(notice) {
+		writeLog(createMessage(msg, nil, copyFields(l.fields, kv), notice))
+	}
+}
+
+func (l *FieldLogger) WarnKV(msg string, kv ...interface{}) {
+	if l.enabled(warn) {
+		writeLog(createMessage(msg, nil, copyFields(l.fields, kv), warn))
+	}
+}
+
+func (l *FieldLogger) ErrorKV(msg string, kv ...interface{}) {
+	if l.enabled(err) {
+		writeLog(createMessage(msg, nil, copyFields(l.fields, kv), err))
+	}
+}
+
+func (l *FieldLogger) CriticalKV(msg string, kv ...interface{}) {
+	if l.enabled(critical) {
+		writeLog(createMessage(msg, nil, copyFields(l.fields, kv), critical))
+	}
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+func copyFields(base []interface{}, kv []interface{}) []interface{} {
+	res := make([]interface{}, 0, len(base)+len(kv)+1)
+	res = append(res, base...)
+	res = append(res, kv...)
+	if len(kv)%2 == 1 {
+		// 最後一個值沒有鍵值
+		res = append(res[:len(res)-1], badFieldKey, res[len(res)-1])
+	}
+	return res
+}
+
+// renderFields : 轉為 " key=value key=value" 格式
+func renderFields(fields []interface{}) string {
+	var buf strings.Builder
+	for i := 0; i+1 < len(fields); i += 2 {
+		buf.WriteByte(' ')
+		buf.WriteString(fieldKey(fields[i]))
+		buf.WriteByte('=')
+		buf.WriteString(fieldText(fields[i+1]))
+	}
+	return buf.String()
+}
+
+func fieldKey(key interface{}) string {
+	if str, ok := key.(string); ok {
+		return str
+	}
+	return fmt.Sprint(key)
+}
+
+func fieldText(value interface{}) string {
+	var str string
+	switch v := value.(type) {
+	case string:
+		str = v
+	case error:
+		str = v.Error()
+	case time.Duration:
+		str = v.String()
+	default:
+		str = fmt.Sprint(v)
+	}
+	if str == "" || strings.ContainsAny(str, " =\"\t\n") {
+		return strconv.Quote(str)
+	}
+	return str
+}
+
+func fieldJSON(value interface{}) []byte {
+	switch v := value.(type) {
+	case error:
+		value = v.Error()
+	case time.Duration:
+		value = v.String()
+	case fmt.Stringer:
+		value = v.String()
+	}
+	out, e := json.Marshal(value)
+	if e != nil {
+		out, _ = json.Marshal(fmt.Sprint(value))
+	}
+	return out
+}
+
+// createLogJSON : 轉為一行 JSON 物件
+func createLogJSON(data *LogRecord) string {
+	var buf bytes.Buffer
+	buf.WriteString(`{"time":`)
+	buf.Write(fieldJSON(data.Time.Format(time.RFC3339Nano)))
+	buf.WriteString(`,"level":`)
+	buf.Write(fieldJSON(data.Level.String()))
+	if outDetail {
+		buf.WriteString(`,"file":`)
+		buf.Write(fieldJSON(data.File))
+		buf.WriteString(`,"line":`)
+		buf.WriteString(strconv.Itoa(data.Line))
+	}
+	buf.WriteString(`,"msg":`)
+	buf.Write(fieldJSON(data.Message))
+	for i := 0; i+1 < len(data.Fields); i += 2 {
+		buf.WriteByte(',')
+		buf.Write(fieldJSON(fieldKey(data.Fields[i])))
+		buf.WriteByte(':')
+		buf.Write(fieldJSON(data.Fields[i+1]))
+	}
+	buf.WriteByte('}')
+	return buf.String()
+}
@@ -0,0 +1,198 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//	Constants
+//------------------------------------------------------------------------------
+
+const (
+	defaultLogHistorySize = 10000
+)
+
+//------------------------------------------------------------------------------
+//	Variables
+//------------------------------------------------------------------------------
+
+var (
+	logHistory = newLogRing(defaultLogHistorySize)
+)
+
+//------------------------------------------------------------------------------
+//	Structure declare
+//------------------------------------------------------------------------------
+
+type (
+	// LogEntry : 記憶體中保留的一行 log
+	LogEntry struct {
+		Seq   int       // 流水號，從 0 開始遞增，不因淘汰舊資料而改變
+		Time  time.Time // 紀錄時間
+		Level LogLevel  // 等級
+		Line  string    // 格式化後的內容
+	}
+
+	// LogQuery : QueryLogs 的條件
+	LogQuery struct {
+		From     int      // 起始流水號，-1 則從最後 Limit 行開始
+		Limit    int      // 最多取得幾行，0 則為 200
+		Level    LogLevel // 最低等級，0 則不過濾
+		Contains string   // 必須包含的字串，空字串則不過濾
+	}
+
+	// logRing : 固定容量的 log 環狀緩衝區
+	logRing struct {
+		entries []LogEntry
+		next    int // 下一筆的流水號
+		subs    map[chan LogEntry]struct{}
+		sync.RWMutex
+	}
+)
+
+//------------------------------------------------------------------------------
+//	Public Methods
+//------------------------------------------------------------------------------
+
+// SetLogHistorySize : 設定記憶體中保留的 log 行數，會保留最新的部分
+// @param	size	行數，必須大於 0
+func SetLogHistorySize(size int) error {
+	if size <= 0 {
+		return errors.New("invalid log history size")
+	}
+	logHistory.resize(size)
+	return nil
+}
+
+// QueryLogs : 依條件取得記憶體中的 log
+// @param	query	查詢條件
+// @return	符合的 log & 下一次查詢的起始流水號
+func QueryLogs(query LogQuery) ([]LogEntry, int) {
+	return logHistory.query(query)
+}
+
+// SubscribeLogs : 訂閱新產生的 log，接收端來不及處理時會丟棄
+// @param	buffer	channel 容量
+// @return	log channel & 取消訂閱的 function，取消後 channel 會被關閉
+func SubscribeLogs(buffer int) (<-chan LogEntry, func()) {
+	return logHistory.subscribe(buffer)
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+func newLogRing(size int) *logRing {
+	return &logRing{
+		entries: make([]LogEntry, size),
+		subs:    make(map[chan LogEntry]struct{}),
+	}
+}
+
+// first : 目前保留的第一筆流水號
+func (r *logRing) first() int {
+	if r.next > len(r.entries) {
+		return r.next - len(r.entries)
+	}
+	return 0
+}
+
+func (r *logRing) at(seq int) LogEntry {
+	return r.entries[seq%len(r.entries)]
+}
+
+func (r *logRing) append(record *LogRecord, line string) {
+	r.Lock()
+	defer r.Unlock()
+	entry := LogEntry{r.next, record.Time, record.Level, line}
+	r.entries[r.next%len(r.entries)] = entry
+	r.next++
+	for sub := range r.subs {
+		select {
+		case sub <- entry:
+		default:
+		}
+	}
+}
+
+func (r *logRing) resize(size int) {
+	r.Lock()
+	defer r.Unlock()
+	entries := make([]LogEntry, size)
+	begin := r.first()
+	if r.next-begin > size {
+		begin = r.next - size
+	}
+	for seq := begin; seq < r.next; seq++ {
+		entries[seq%size] = r.at(seq)
+	}
+	r.entries = entries
+}
+
+func (r *logRing) query(query LogQuery) ([]LogEntry, int) {
+	if query.Limit <= 0 {
+		query.Limit = onceFetchLines
+	}
+	r.RLock()
+	defer r.RUnlock()
+	first := r.first()
+	pos := query.From
+	if pos < 0 {
+		pos = r.next - query.Limit
+	}
+	if pos < first {
+		pos = first
+	}
+	var res []LogEntry
+	for ; pos < r.next && len(res) < query.Limit; pos++ {
+		entry := r.at(pos)
+		if query.Level > 0 && entry.Level > query.Level {
+			continue
+		}
+		if query.Contains != "" && !strings.Contains(entry.Line, query.Contains) {
+			continue
+		}
+		res = append(res, entry)
+	}
+	return res, pos
+}
+
+func (r *logRing) subscribe(buffer int) (<-chan LogEntry, func()) {
+	sub := make(chan LogEntry, buffer)
+	r.Lock()
+	r.subs[sub] = struct{}{}
+	r.Unlock()
+	var once sync.Once
+	return sub, func() {
+		once.Do(func() {
+			r.Lock()
+			delete(r.subs, sub)
+			r.Unlock()
+			close(sub)
+		})
+	}
+}
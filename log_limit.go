@@ -0,0 +1,248 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//	Constants
+//------------------------------------------------------------------------------
+
+const (
+	// 檢查是否有到期的 suppressed 計數的間隔
+	logLimitFlushInterval = 100 * time.Millisecond
+)
+
+//------------------------------------------------------------------------------
+//	Variables
+//------------------------------------------------------------------------------
+
+var (
+	logLimits = &logLimiter{
+		active: NewInterlockBool(false),
+		levels: make(map[LogLevel]LogRateLimit),
+		keys:   make(map[string]LogRateLimit),
+		sites:  make(map[string]*logSite),
+	}
+)
+
+//------------------------------------------------------------------------------
+//	Structure declare
+//------------------------------------------------------------------------------
+
+type (
+	// LogRateLimit : 同一個呼叫位置 (檔案:行號) 的 log 輸出限制。
+	// 每個 Period 內先輸出 First 筆，之後每 Every 筆輸出一筆；被略過的數量會在
+	// Period 結束後以 "suppressed" 訊息補上
+	LogRateLimit struct {
+		First  int           // 每個週期完整輸出的筆數
+		Every  int           // 超過 First 後每幾筆輸出一筆，0 則全部略過
+		Period time.Duration // 週期，0 則為 1 秒
+	}
+
+	logLimiter struct {
+		active *InterlockBool // 有任何設定時才檢查
+		levels map[LogLevel]LogRateLimit
+		keys   map[string]LogRateLimit // 以訊息的 "Type:Method" 前綴設定
+		sites  map[string]*logSite
+		flush  bool // flushLoop 執行中
+		sync.Mutex
+	}
+
+	// logSite : 單一呼叫位置的計數
+	logSite struct {
+		begin      time.Time
+		period     time.Duration
+		count      int
+		suppressed int
+		level      LogLevel // 最後一筆被略過的等級
+		file       string
+		line       int
+	}
+)
+
+//------------------------------------------------------------------------------
+//	Public Methods
+//------------------------------------------------------------------------------
+
+// SetLogRateLimit : 設定某個等級的 log 輸出限制
+// @param	level	log 等級
+// @param	limit	輸出限制，First 與 Every 皆為 0 則取消限制
+func SetLogRateLimit(level LogLevel, limit LogRateLimit) error {
+	if _, ok := levelNames[int(level)]; !ok {
+		return errors.New("Invalid log level: " + fmt.Sprint(int(level)))
+	}
+	return logLimits.set(func() {
+		if limit.First == 0 && limit.Every == 0 {
+			delete(logLimits.levels, level)
+		} else {
+			logLimits.levels[level] = limit
+		}
+	}, limit)
+}
+
+// SetLogRateLimitFor : 設定特定訊息的 log 輸出限制，優先於 SetLogRateLimit
+// @param	key	訊息開頭的 "Type:Method"，ex: "Connector:SendCommand"
+// @param	limit	輸出限制，First 與 Every 皆為 0 則取消限制
+func SetLogRateLimitFor(key string, limit LogRateLimit) error {
+	if key == "" {
+		return errors.New("empty log rate limit key")
+	}
+	return logLimits.set(func() {
+		if limit.First == 0 && limit.Every == 0 {
+			delete(logLimits.keys, key)
+		} else {
+			logLimits.keys[key] = limit
+		}
+	}, limit)
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+func (l *logLimiter) set(apply func(), limit LogRateLimit) error {
+	if limit.First < 0 || limit.Every < 0 || limit.Period < 0 {
+		return errors.New("invalid log rate limit")
+	}
+	l.Lock()
+	defer l.Unlock()
+	apply()
+	// 設定改變後重新計數
+	l.sites = make(map[string]*logSite)
+	if len(l.levels) > 0 || len(l.keys) > 0 {
+		l.active.True()
+	} else {
+		l.active.False()
+	}
+	return nil
+}
+
+// allow : 檢查是否輸出
+// @return	是否輸出 & 需要先輸出的 suppressed 訊息
+func (l *logLimiter) allow(data *LogRecord) (bool, *LogRecord) {
+	if !l.active.Value() {
+		return true, nil
+	}
+	l.Lock()
+	defer l.Unlock()
+	limit, ok := l.rule(data)
+	if !ok {
+		return true, nil
+	}
+	if limit.Period <= 0 {
+		limit.Period = time.Second
+	}
+	where := fmt.Sprint(data.File, ":", data.Line)
+	site, ok := l.sites[where]
+	if !ok {
+		site = &logSite{begin: data.Time, file: data.File, line: data.Line}
+		l.sites[where] = site
+	}
+	site.period = limit.Period
+	var summary *LogRecord
+	if data.Time.Sub(site.begin) >= limit.Period {
+		// flushLoop 尚未補上的計數
+		summary = site.summary(data.Time)
+		site.begin = data.Time
+		site.count = 0
+	}
+	site.count++
+	if site.count <= limit.First ||
+		(limit.Every > 0 && (site.count-limit.First)%limit.Every == 0) {
+		return true, summary
+	}
+	site.suppressed++
+	site.level = data.Level
+	if !l.flush {
+		l.flush = true
+		go l.flushLoop()
+	}
+	return false, summary
+}
+
+// flushLoop : 週期結束後補上 suppressed 訊息，不必等該位置再次輸出；
+// 沒有待補的計數時結束
+func (l *logLimiter) flushLoop() {
+	ticker := time.NewTicker(logLimitFlushInterval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		l.Lock()
+		var summaries []*LogRecord
+		pending := false
+		for _, site := range l.sites {
+			if site.suppressed == 0 {
+				continue
+			}
+			if now.Sub(site.begin) < site.period {
+				pending = true
+				continue
+			}
+			summaries = append(summaries, site.summary(now))
+		}
+		if !pending {
+			l.flush = false
+		}
+		l.Unlock()
+		for _, summary := range summaries {
+			writeRecord(summary)
+		}
+		if !pending {
+			return
+		}
+	}
+}
+
+// summary : 取出被略過的數量，沒有則回傳 nil
+func (s *logSite) summary(now time.Time) *LogRecord {
+	if s.suppressed == 0 {
+		return nil
+	}
+	res := &LogRecord{
+		Time:    now,
+		Level:   s.level,
+		File:    s.file,
+		Line:    s.line,
+		Message: fmt.Sprintf("log:rate: suppressed messages. COUNT=%d, SITE=%s:%d", s.suppressed, s.file, s.line),
+	}
+	s.suppressed = 0
+	return res
+}
+
+// rule : 先找訊息前綴的設定，再找等級的設定
+func (l *logLimiter) rule(data *LogRecord) (LogRateLimit, bool) {
+	if len(l.keys) > 0 {
+		if i := strings.Index(data.Message, ": "); i > 0 {
+			if limit, ok := l.keys[data.Message[:i]]; ok {
+				return limit, true
+			}
+		}
+	}
+	limit, ok := l.levels[data.Level]
+	return limit, ok
+}
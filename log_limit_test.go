@@ -0,0 +1,79 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//	Tests
+//------------------------------------------------------------------------------
+
+func TestLogRateLimitFlush(t *testing.T) {
+	capture := NewCaptureSink()
+	if err := AddLogSink("log_limit_test", capture, SinkConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	defer RemoveLogSink("log_limit_test")
+	limit := LogRateLimit{First: 2, Period: 200 * time.Millisecond}
+	if err := SetLogRateLimitFor("Test:flush", limit); err != nil {
+		t.Fatal(err)
+	}
+	defer SetLogRateLimitFor("Test:flush", LogRateLimit{})
+
+	for i := 0; i < 5; i++ {
+		Warn("Test:flush: message. N=%d", i)
+	}
+	if n := countLines(capture, "Test:flush: message"); n != 2 {
+		t.Fatalf("unexpected output count %d", n)
+	}
+	// 同一位置不再輸出，週期結束後仍然要補上 suppressed 計數
+	deadline := time.Now().Add(5 * time.Second)
+	for countLines(capture, "suppressed messages. COUNT=3") == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("suppressed count not flushed. LINES=%v", capture.Lines())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(2 * logLimitFlushInterval)
+	if n := countLines(capture, "suppressed messages"); n != 1 {
+		t.Fatalf("suppressed count flushed %d times", n)
+	}
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+// countLines : 包含 substr 的行數
+func countLines(capture *CaptureSink, substr string) int {
+	n := 0
+	for _, line := range capture.Lines() {
+		if strings.Contains(line, substr) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,212 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"errors"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//------------------------------------------------------------------------------
+//	Constants
+//------------------------------------------------------------------------------
+
+const (
+	// 以環境變數設定各模組等級，ex: AGENCY_LOG=connector=debug,*=info
+	logLevelEnv = "AGENCY_LOG"
+	// 代表全域等級的模組名稱
+	globalModuleName = "*"
+)
+
+//------------------------------------------------------------------------------
+//	Variables
+//------------------------------------------------------------------------------
+
+var (
+	logModules    = make(map[string]*logModule)
+	logModuleLock sync.RWMutex
+
+	// 內部元件所使用的模組 logger
+	poolLog      = ModuleLogger("pool")
+	connectorLog = ModuleLogger("connector")
+	barrierLog   = ModuleLogger("barrier")
+	managerLog   = ModuleLogger("manager")
+	adminLog     = ModuleLogger("admin")
+)
+
+//------------------------------------------------------------------------------
+//	Structure declare
+//------------------------------------------------------------------------------
+
+type (
+	// logModule : 具名模組的等級，0 表示沿用全域等級
+	logModule struct {
+		name  string
+		level *InterlockInt32
+	}
+)
+
+//------------------------------------------------------------------------------
+//	Public Methods
+//------------------------------------------------------------------------------
+
+// ModuleLogger : 取得具名模組的 logger，等級可以透過 SetModuleLogLevel 單獨設定。
+// 內建的模組有 "pool", "connector", "barrier", "manager", "admin"
+// @param	name	模組名稱
+func ModuleLogger(name string) *FieldLogger {
+	return &FieldLogger{module: getLogModule(name)}
+}
+
+// SetModuleLogLevel : 設定模組的等級
+// @param	name	模組名稱，"*" 則設定全域等級 (同 SetLogLevel)
+// @param	level	"DEBUG", "INFO", "NOTICE", "WARN", "ERROR", "CRIT"；空字串則恢復為沿用全域等級
+func SetModuleLogLevel(name, level string) error {
+	if name == globalModuleName {
+		return SetLogLevel(level)
+	}
+	if name == "" {
+		return errors.New("empty log module name")
+	}
+	var numLevel LogLevel
+	if level != "" {
+		var err error
+		if numLevel, err = ParseLogLevel(level); err != nil {
+			return err
+		}
+	}
+	getLogModule(name).level.Exchange(int32(numLevel))
+	return nil
+}
+
+// GetModuleLogLevels : 取得全域與各模組目前的等級，沿用全域等級的模組不列出
+func GetModuleLogLevels() map[string]string {
+	res := map[string]string{
+		globalModuleName: LogLevel(logLevel.Value()).String(),
+	}
+	logModuleLock.RLock()
+	defer logModuleLock.RUnlock()
+	for name, module := range logModules {
+		if level := module.level.Value(); level > 0 {
+			res[name] = LogLevel(level).String()
+		}
+	}
+	return res
+}
+
+// ApplyLogLevels : 一次設定多個模組的等級，ex: "connector=debug,pool=warn,*=info"
+// @param	spec	以逗號分隔的 模組=等級，不可為空
+func ApplyLogLevels(spec string) error {
+	type pair struct{ name, level string }
+	var pairs []pair
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 {
+			return errors.New("Invalid log level spec: " + item)
+		}
+		name, level := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		if _, err := ParseLogLevel(level); err != nil || name == "" {
+			return errors.New("Invalid log level spec: " + item)
+		}
+		pairs = append(pairs, pair{name, level})
+	}
+	if len(pairs) == 0 {
+		return errors.New("Empty log level spec")
+	}
+	// 全部檢查通過後才套用
+	for _, p := range pairs {
+		SetModuleLogLevel(p.name, p.level)
+	}
+	return nil
+}
+
+// GetLogModuleNames : 取得目前所有模組的名稱
+func GetLogModuleNames() []string {
+	logModuleLock.RLock()
+	res := make([]string, 0, len(logModules))
+	for name := range logModules {
+		res = append(res, name)
+	}
+	logModuleLock.RUnlock()
+	sort.Strings(res)
+	return res
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+// applyLogLevelEnv : 套用 AGENCY_LOG，LoggerSetup 之後再套用一次，環境變數優先於程式中的設定
+func applyLogLevelEnv() error {
+	spec := os.Getenv(logLevelEnv)
+	if spec == "" {
+		return nil
+	}
+	return ApplyLogLevels(spec)
+}
+
+func getLogModule(name string) *logModule {
+	logModuleLock.RLock()
+	module, ok := logModules[name]
+	logModuleLock.RUnlock()
+	if ok {
+		return module
+	}
+	logModuleLock.Lock()
+	defer logModuleLock.Unlock()
+	if module, ok = logModules[name]; !ok {
+		module = &logModule{name, NewInterlockInt32(0)}
+		logModules[name] = module
+	}
+	return module
+}
+
+// levelEnabled : 全域等級是否輸出
+func levelEnabled(level int) bool {
+	return int(logLevel.Value()) >= level
+}
+
+// enabled : 模組有設定等級時以模組為準，否則沿用全域等級
+func (l *FieldLogger) enabled(level int) bool {
+	if l.module != nil {
+		if own := l.module.level.Value(); own > 0 {
+			return int(own) >= level
+		}
+	}
+	return levelEnabled(level)
+}
+
+//------------------------------------------------------------------------------
+//	Auto initialize function
+//------------------------------------------------------------------------------
+
+func init() {
+	if err := applyLogLevelEnv(); err != nil {
+		os.Stderr.WriteString("log:init: " + logLevelEnv + " ignored. ERR=" + err.Error() + "\n")
+	}
+}
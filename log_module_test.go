@@ -0,0 +1,79 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+)
+
+//------------------------------------------------------------------------------
+//	Tests
+//------------------------------------------------------------------------------
+
+func TestLoggerSetupKeepsEnv(t *testing.T) {
+	levels := GetModuleLogLevels()
+	defer restoreLogLevels(levels)
+	os.Setenv(logLevelEnv, "log_module_test=debug,*=warn")
+	defer os.Unsetenv(logLevelEnv)
+
+	LoggerSetup(false, false, "INFO")
+	got := GetModuleLogLevels()
+	if got[globalModuleName] != "WARN" || got["log_module_test"] != "DEBUG" {
+		t.Fatalf("%s overwritten by LoggerSetup. LEVELS=%v", logLevelEnv, got)
+	}
+}
+
+func TestAdminLogLevels(t *testing.T) {
+	levels := GetModuleLogLevels()
+	defer restoreLogLevels(levels)
+	admin := NewAdminServer("127.0.0.1:0")
+	for spec, status := range map[string]int{
+		"log_module_test=debug": http.StatusOK,
+		"":                      http.StatusBadRequest,
+		" , ":                   http.StatusBadRequest,
+		"debug":                 http.StatusBadRequest,
+		"log_module_test=loud":  http.StatusBadRequest,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/logs/levels?spec="+url.QueryEscape(spec), nil)
+		rec := httptest.NewRecorder()
+		admin.mux.ServeHTTP(rec, req)
+		if rec.Code != status {
+			t.Errorf("spec %q: unexpected status %d", spec, rec.Code)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+// restoreLogLevels : 還原 GetModuleLogLevels 取得的等級，測試中新增的模組改回沿用全域等級
+func restoreLogLevels(levels map[string]string) {
+	for _, name := range GetLogModuleNames() {
+		SetModuleLogLevel(name, levels[name])
+	}
+	SetLogLevel(levels[globalModuleName])
+}
@@ -0,0 +1,349 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//	Constants
+//------------------------------------------------------------------------------
+
+const (
+	rotateTimeFormat = "20060102-150405"
+	rotateExt        = ".txt"
+	rotateGzipExt    = ".gz"
+)
+
+//------------------------------------------------------------------------------
+//	Structure declare
+//------------------------------------------------------------------------------
+
+type (
+	// RotateConfig : 輪替 log 檔的設定
+	//	寫入中的檔案為 <Dir>/<Prefix>.txt，輪替後改名為 <Prefix>_<開檔時間>.txt[.gz]
+	RotateConfig struct {
+		Dir            string        // 存放目錄，空字串為目前目錄
+		Prefix         string        // 檔名前綴，空字串為 GetAppName()
+		MaxSize        int64         // 單檔大小上限 (bytes)，0 則不依大小輪替
+		Daily          bool          // 跨日時輪替
+		MaxBackups     int           // 保留的舊檔數量，0 則不限制
+		MaxAge         time.Duration // 舊檔保留時間，0 則不限制
+		Compress       bool          // 以 gzip 壓縮舊檔
+		ReopenOnSignal bool          // 收到 SIGHUP 時重新開檔，配合外部的 logrotate
+	}
+
+	// RotatingFileSink : 可依大小或日期輪替的檔案 sink
+	RotatingFileSink struct {
+		config  RotateConfig
+		file    *os.File
+		size    int64
+		openAt  time.Time
+		signals chan os.Signal
+		mill    sync.Mutex // 壓縮與清理舊檔
+		sync.Mutex
+	}
+)
+
+//------------------------------------------------------------------------------
+//	Public Methods
+//------------------------------------------------------------------------------
+
+// OpenRotatingLogFile : 以輪替檔案取代 OpenLogFile 所建立的 "file" sink
+// @param	config	輪替設定
+func OpenRotatingLogFile(config RotateConfig) error {
+	sink, err := NewRotatingFileSink(config)
+	if err != nil {
+		return err
+	}
+	RemoveLogSink(fileSinkName)
+	if err = AddLogSink(fileSinkName, sink, SinkConfig{Formatter: defaultFormatter(false)}); err != nil {
+		sink.Close()
+		return err
+	}
+	return nil
+}
+
+// NewRotatingFileSink : RotatingFileSink object creator.
+// @param	config	輪替設定
+func NewRotatingFileSink(config RotateConfig) (*RotatingFileSink, error) {
+	if config.Prefix == "" {
+		config.Prefix = GetAppName()
+	}
+	if config.MaxSize < 0 || config.MaxBackups < 0 || config.MaxAge < 0 {
+		return nil, errors.New("invalid rotate config")
+	}
+	if config.Dir != "" {
+		if err := os.MkdirAll(config.Dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	s := &RotatingFileSink{config: config}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	if config.ReopenOnSignal {
+		s.signals = make(chan os.Signal, 1)
+		signal.Notify(s.signals, syscall.SIGHUP)
+		go s.watchSignal(s.signals)
+	}
+	return s, nil
+}
+
+// Filename : 寫入中的檔案路徑
+func (s *RotatingFileSink) Filename() string {
+	return filepath.Join(s.config.Dir, s.config.Prefix+rotateExt)
+}
+
+// WriteLog : implement Sink
+func (s *RotatingFileSink) WriteLog(record *LogRecord, line string) error {
+	s.Lock()
+	defer s.Unlock()
+	if s.file == nil {
+		return errors.New("file closed")
+	}
+	size := int64(len(line) + 1)
+	if s.shouldRotate(record.Time, size) {
+		// 改名失敗時仍寫入目前的檔案
+		if err := s.rotate(); err != nil && s.file == nil {
+			return err
+		}
+	}
+	n, err := io.WriteString(s.file, line+"\n")
+	s.size += int64(n)
+	return err
+}
+
+// Rotate : 立即輪替
+func (s *RotatingFileSink) Rotate() error {
+	s.Lock()
+	defer s.Unlock()
+	if s.file == nil {
+		return errors.New("file closed")
+	}
+	return s.rotate()
+}
+
+// Reopen : 關閉後以相同路徑重新開檔，檔案已被外部程式改名時使用
+func (s *RotatingFileSink) Reopen() error {
+	s.Lock()
+	defer s.Unlock()
+	if s.file == nil {
+		return errors.New("file closed")
+	}
+	s.file.Close()
+	s.file = nil
+	return s.open()
+}
+
+// Close : implement Sink
+func (s *RotatingFileSink) Close() error {
+	s.Lock()
+	defer s.Unlock()
+	if s.signals != nil {
+		signal.Stop(s.signals)
+		close(s.signals)
+		s.signals = nil
+	}
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+func (s *RotatingFileSink) open() error {
+	file, err := os.OpenFile(s.Filename(), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	s.file = file
+	s.size = info.Size()
+	s.openAt = time.Now()
+	if s.size > 0 {
+		// 沿用既有的檔案，以最後修改時間判斷是否跨日
+		s.openAt = info.ModTime()
+	}
+	return nil
+}
+
+func (s *RotatingFileSink) shouldRotate(now time.Time, size int64) bool {
+	if s.size == 0 {
+		return false
+	}
+	if s.config.MaxSize > 0 && s.size+size > s.config.MaxSize {
+		return true
+	}
+	if s.config.Daily {
+		y1, m1, d1 := s.openAt.Date()
+		y2, m2, d2 := now.Date()
+		return y1 != y2 || m1 != m2 || d1 != d2
+	}
+	return false
+}
+
+// rotate : 改名後開新檔；改名失敗時保留目前的檔案，並重新計算大小與日期，
+// 等下一次觸發時再重試，避免每一筆 log 都重試
+func (s *RotatingFileSink) rotate() error {
+	backup := s.backupName()
+	if err := os.Rename(s.Filename(), backup); err != nil {
+		fmt.Fprintln(os.Stderr, "log:RotatingFileSink: rename failed. ERR=", err.Error())
+		s.size = 0
+		s.openAt = time.Now()
+		return err
+	}
+	if err := s.file.Close(); err != nil {
+		fmt.Fprintln(os.Stderr, "log:RotatingFileSink: close failed. ERR=", err.Error())
+	}
+	s.file = nil
+	if err := s.open(); err != nil {
+		return err
+	}
+	go s.millBackups(backup)
+	return nil
+}
+
+// backupName : 以開檔時間命名，同一秒內重複輪替時加上序號
+func (s *RotatingFileSink) backupName() string {
+	base := filepath.Join(s.config.Dir, s.config.Prefix+"_"+s.openAt.Format(rotateTimeFormat))
+	name := base + rotateExt
+	for i := 1; fileExists(name) || fileExists(name+rotateGzipExt); i++ {
+		name = fmt.Sprintf("%s-%d%s", base, i, rotateExt)
+	}
+	return name
+}
+
+func (s *RotatingFileSink) watchSignal(signals chan os.Signal) {
+	for range signals {
+		if err := s.Reopen(); err != nil {
+			fmt.Fprintln(os.Stderr, "log:RotatingFileSink: reopen failed. ERR=", err.Error())
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// millBackups : 壓縮剛輪替的檔案並清除過期的舊檔
+func (s *RotatingFileSink) millBackups(backup string) {
+	s.mill.Lock()
+	defer s.mill.Unlock()
+	if s.config.Compress {
+		if err := gzipFile(backup); err != nil {
+			fmt.Fprintln(os.Stderr, "log:RotatingFileSink: compress failed. FILE=", backup, ", ERR=", err.Error())
+		}
+	}
+	if s.config.MaxBackups == 0 && s.config.MaxAge == 0 {
+		return
+	}
+	backups, err := s.listBackups()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "log:RotatingFileSink: list failed. ERR=", err.Error())
+		return
+	}
+	now := time.Now()
+	for i, info := range backups {
+		expired := s.config.MaxAge > 0 && now.Sub(info.ModTime()) > s.config.MaxAge
+		if (s.config.MaxBackups > 0 && i >= s.config.MaxBackups) || expired {
+			os.Remove(filepath.Join(s.config.Dir, info.Name()))
+		}
+	}
+}
+
+// listBackups : 取得舊檔，由新到舊
+func (s *RotatingFileSink) listBackups() ([]os.FileInfo, error) {
+	dir := s.config.Dir
+	if dir == "" {
+		dir = "."
+	}
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	prefix := s.config.Prefix + "_"
+	var res []os.FileInfo
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if strings.HasSuffix(name, rotateExt) || strings.HasSuffix(name, rotateExt+rotateGzipExt) {
+			res = append(res, info)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ModTime().After(res[j].ModTime()) })
+	return res, nil
+}
+
+//------------------------------------------------------------------------------
+
+func gzipFile(name string) error {
+	in, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(name+rotateGzipExt, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	if _, err = io.Copy(zw, in); err == nil {
+		err = zw.Close()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(name + rotateGzipExt)
+		return err
+	}
+	in.Close()
+	return os.Remove(name)
+}
+
+func fileExists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
@@ -0,0 +1,279 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+//------------------------------------------------------------------------------
+//	Constants
+//------------------------------------------------------------------------------
+
+const (
+	// 內建 sink 名稱
+	consoleSinkName = "console"
+	fileSinkName    = "file"
+	historySinkName = "history"
+)
+
+//------------------------------------------------------------------------------
+//	Variables
+//------------------------------------------------------------------------------
+
+var (
+	// 目前所有的輸出，由 mutex 保護
+	logSinks []*sinkEntry
+)
+
+//------------------------------------------------------------------------------
+//	Structure declare
+//------------------------------------------------------------------------------
+
+type (
+	// Sink : log 輸出目標
+	Sink interface {
+		// WriteLog : 寫出一筆已經格式化的 log，line 不含換行
+		WriteLog(record *LogRecord, line string) error
+		// Close : 移除 sink 時呼叫
+		Close() error
+	}
+
+	// Formatter : 將 LogRecord 轉為一行文字
+	Formatter interface {
+		Format(record *LogRecord) string
+	}
+
+	// SinkConfig : sink 的設定
+	SinkConfig struct {
+		Level     LogLevel  // 最低輸出等級，0 則全部輸出
+		Formatter Formatter // nil 則使用 ConsoleFormatter
+	}
+
+	// ConsoleFormatter : 原本的文字格式 "[I] 2006-01-02 15:04:05  ▶  message key=value"
+	ConsoleFormatter struct {
+		Color bool // 是否加上 ANSI 顏色
+	}
+
+	// JSONFormatter : 一行一個 JSON 物件
+	JSONFormatter struct{}
+
+	// WriterSink : 輸出至 io.Writer 的 sink，ex: os.Stderr, 檔案
+	WriterSink struct {
+		out io.Writer
+	}
+
+	// CaptureSink : 將 log 保留在記憶體中的 sink，測試用
+	CaptureSink struct {
+		lines   []string
+		records []LogRecord
+		sync.Mutex
+	}
+
+	// historySink : 給 GetLogContents 使用的記憶體 log
+	historySink struct{}
+
+	sinkEntry struct {
+		name      string
+		sink      Sink
+		level     LogLevel
+		formatter Formatter
+	}
+)
+
+//------------------------------------------------------------------------------
+//	Public Methods
+//------------------------------------------------------------------------------
+
+// AddLogSink : 新增 log 輸出目標。內建的有 "console" (stdout)、"history"
+// (GetLogContents) 與 OpenLogFile 建立的 "file"
+// @param	name	名稱，不可重複
+// @param	sink	輸出目標
+// @param	config	最低等級與格式
+func AddLogSink(name string, sink Sink, config SinkConfig) error {
+	if sink == nil {
+		return errors.New("nil sink")
+	}
+	if config.Formatter == nil {
+		config.Formatter = ConsoleFormatter{}
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	for _, entry := range logSinks {
+		if entry.name == name {
+			return errors.New("duplicate log sink: " + name)
+		}
+	}
+	logSinks = append(logSinks, &sinkEntry{name, sink, config.Level, config.Formatter})
+	return nil
+}
+
+// RemoveLogSink : 移除並關閉 log 輸出目標
+// @param	name	AddLogSink 時的名稱
+func RemoveLogSink(name string) error {
+	mutex.Lock()
+	var found *sinkEntry
+	for i, entry := range logSinks {
+		if entry.name == name {
+			found = entry
+			logSinks = append(logSinks[:i:i], logSinks[i+1:]...)
+			break
+		}
+	}
+	mutex.Unlock()
+	if found == nil {
+		return errors.New("log sink not found: " + name)
+	}
+	return found.sink.Close()
+}
+
+// GetLogSinkNames : 取得目前所有 log 輸出目標的名稱
+func GetLogSinkNames() []string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	res := make([]string, len(logSinks))
+	for i, entry := range logSinks {
+		res[i] = entry.name
+	}
+	return res
+}
+
+//------------------------------------------------------------------------------
+
+// Format : implement Formatter
+func (f ConsoleFormatter) Format(record *LogRecord) string {
+	return createLogString(record, f.Color && colorable)
+}
+
+// Format : implement Formatter
+func (f JSONFormatter) Format(record *LogRecord) string {
+	return createLogJSON(record)
+}
+
+//------------------------------------------------------------------------------
+
+// NewWriterSink : 建立輸出至 io.Writer 的 sink，移除時若 writer 為 io.Closer
+// (os.Stdout, os.Stderr 除外) 則一併關閉
+func NewWriterSink(out io.Writer) *WriterSink {
+	return &WriterSink{out}
+}
+
+// WriteLog : implement Sink
+func (s *WriterSink) WriteLog(record *LogRecord, line string) error {
+	_, err := io.WriteString(s.out, line+"\n")
+	return err
+}
+
+// Close : implement Sink
+func (s *WriterSink) Close() error {
+	if s.out == os.Stdout || s.out == os.Stderr || s.out == colorWriter {
+		return nil
+	}
+	if closer, ok := s.out.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// NewCaptureSink : CaptureSink object creator.
+func NewCaptureSink() *CaptureSink {
+	return &CaptureSink{}
+}
+
+// WriteLog : implement Sink
+func (s *CaptureSink) WriteLog(record *LogRecord, line string) error {
+	s.Lock()
+	defer s.Unlock()
+	s.lines = append(s.lines, line)
+	s.records = append(s.records, *record)
+	return nil
+}
+
+// Close : implement Sink
+func (s *CaptureSink) Close() error {
+	return nil
+}
+
+// Lines : 取得目前收到的 log
+func (s *CaptureSink) Lines() []string {
+	s.Lock()
+	defer s.Unlock()
+	res := make([]string, len(s.lines))
+	copy(res, s.lines)
+	return res
+}
+
+// Records : 取得目前收到的 LogRecord
+func (s *CaptureSink) Records() []LogRecord {
+	s.Lock()
+	defer s.Unlock()
+	res := make([]LogRecord, len(s.records))
+	copy(res, s.records)
+	return res
+}
+
+// Reset : 清除目前收到的 log
+func (s *CaptureSink) Reset() {
+	s.Lock()
+	defer s.Unlock()
+	s.lines = nil
+	s.records = nil
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+func (h historySink) WriteLog(record *LogRecord, line string) error {
+	logHistory.append(record, line)
+	return nil
+}
+
+func (h historySink) Close() error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func (e *sinkEntry) write(record *LogRecord) {
+	if e.level > 0 && record.Level > e.level {
+		return
+	}
+	if err := e.sink.WriteLog(record, e.formatter.Format(record)); err != nil {
+		// 不能再寫 log，避免遞迴
+		fmt.Fprintln(os.Stderr, "log:writeLog: sink failed. SINK=", e.name, ", ERR=", err.Error())
+	}
+}
+
+// defaultFormatter : 依 SetLogFormat 的設定取得內建 sink 的格式
+func defaultFormatter(color bool) Formatter {
+	if logFormat == LogFormatJSON {
+		return JSONFormatter{}
+	}
+	return ConsoleFormatter{Color: color}
+}
@@ -0,0 +1,240 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//	Constants
+//------------------------------------------------------------------------------
+
+const (
+	// 連線與寫出的逾時，避免 log 卡住呼叫者
+	netSinkTimeout = 3 * time.Second
+	// 連線失敗後第一次重試前的等待時間，之後每次加倍
+	netSinkMinRetry = time.Second
+	// 重試等待時間的上限
+	netSinkMaxRetry = 30 * time.Second
+	// syslog facility: user-level messages
+	syslogFacilityUser = 1
+)
+
+//------------------------------------------------------------------------------
+//	Variables
+//------------------------------------------------------------------------------
+
+var (
+	// LogLevel 對應的 syslog severity
+	syslogSeverity = map[LogLevel]int{
+		LogLevelCritical: 2,
+		LogLevelError:    3,
+		LogLevelWarn:     4,
+		LogLevelNotice:   5,
+		LogLevelInfo:     6,
+		LogLevelDebug:    7,
+	}
+)
+
+//------------------------------------------------------------------------------
+//	Structure declare
+//------------------------------------------------------------------------------
+
+type (
+	// NetworkSink : 以 TCP/UDP 一行一筆送往 log collector 的 sink
+	NetworkSink struct {
+		netConn
+	}
+
+	// SyslogSink : 以 RFC 3164 格式送往 syslog 的 sink
+	SyslogSink struct {
+		tag      string
+		hostname string
+		local    bool // 本機 unix socket，不需要 hostname
+		netConn
+	}
+
+	// netConn : 斷線時於下次寫出自動重連，連線失敗後以退避時間重試，
+	// 等待期間的 log 直接丟棄，避免在 log 的 mutex 中反覆等待連線逾時
+	netConn struct {
+		network string
+		address string
+		conn    net.Conn
+		retryAt time.Time     // 下次可以重新連線的時間
+		delay   time.Duration // 目前的重試等待時間
+		dropped int           // 斷線期間丟棄的筆數
+		sync.Mutex
+	}
+)
+
+//------------------------------------------------------------------------------
+//	Public Methods
+//------------------------------------------------------------------------------
+
+// NewNetworkSink : 建立送往 log collector 的 sink
+// @param	network	"tcp" or "udp"
+// @param	address	collector 位置, ex: "10.0.0.1:5140"
+func NewNetworkSink(network, address string) (*NetworkSink, error) {
+	s := &NetworkSink{netConn{network: network, address: address}}
+	if err := s.dial(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// WriteLog : implement Sink
+func (s *NetworkSink) WriteLog(record *LogRecord, line string) error {
+	return s.write([]byte(line + "\n"))
+}
+
+// Close : implement Sink
+func (s *NetworkSink) Close() error {
+	return s.close()
+}
+
+//------------------------------------------------------------------------------
+
+// NewSyslogSink : 建立送往 syslog 的 sink
+// @param	network	"unixgram", "unix", "udp" or "tcp"；空字串則使用本機的 /dev/log
+// @param	address	syslog 位置
+// @param	tag		程式名稱，空字串則使用 GetAppName()
+func NewSyslogSink(network, address, tag string) (*SyslogSink, error) {
+	if network == "" {
+		network = "unixgram"
+		address = "/dev/log"
+	}
+	if tag == "" {
+		tag = GetAppName()
+	}
+	hostname, _ := os.Hostname()
+	s := &SyslogSink{
+		tag:      tag,
+		hostname: hostname,
+		local:    network == "unixgram" || network == "unix",
+		netConn:  netConn{network: network, address: address},
+	}
+	if err := s.dial(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// WriteLog : implement Sink
+func (s *SyslogSink) WriteLog(record *LogRecord, line string) error {
+	severity, ok := syslogSeverity[record.Level]
+	if !ok {
+		severity = syslogSeverity[LogLevelInfo]
+	}
+	priority := syslogFacilityUser*8 + severity
+	msg := "<" + strconv.Itoa(priority) + ">" + record.Time.Format(time.Stamp) + " "
+	if !s.local {
+		msg += s.hostname + " "
+	}
+	msg += s.tag + "[" + strconv.Itoa(os.Getpid()) + "]: " + line
+	if s.network == "tcp" {
+		msg += "\n"
+	}
+	return s.write([]byte(msg))
+}
+
+// Close : implement Sink
+func (s *SyslogSink) Close() error {
+	return s.close()
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+func (c *netConn) dial() error {
+	conn, err := net.DialTimeout(c.network, c.address, netSinkTimeout)
+	if err != nil {
+		return err
+	}
+	c.conn = conn
+	return nil
+}
+
+func (c *netConn) write(data []byte) error {
+	c.Lock()
+	defer c.Unlock()
+	if c.conn == nil {
+		if err := c.redial(); err != nil {
+			return err
+		}
+		if c.conn == nil {
+			return nil
+		}
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(netSinkTimeout))
+	if _, err := c.conn.Write(data); err != nil {
+		// 下次重新連線
+		c.conn.Close()
+		c.conn = nil
+		return err
+	}
+	return nil
+}
+
+// redial : 重新連線，還沒到重試時間時丟棄此筆 log
+// @return	連線失敗的錯誤，只在實際嘗試連線時回傳
+func (c *netConn) redial() error {
+	now := time.Now()
+	if now.Before(c.retryAt) {
+		c.dropped++
+		return nil
+	}
+	if err := c.dial(); err != nil {
+		c.delay *= 2
+		if c.delay < netSinkMinRetry {
+			c.delay = netSinkMinRetry
+		} else if c.delay > netSinkMaxRetry {
+			c.delay = netSinkMaxRetry
+		}
+		c.retryAt = now.Add(c.delay)
+		c.dropped++
+		return fmt.Errorf("%s (retry after %s)", err.Error(), c.delay)
+	}
+	if c.dropped > 0 {
+		// 不能再寫 log，避免遞迴
+		fmt.Fprintln(os.Stderr, "log:netConn: reconnected. ADDR=", c.address, ", DROPPED=", c.dropped)
+	}
+	c.delay, c.retryAt, c.dropped = 0, time.Time{}, 0
+	return nil
+}
+
+func (c *netConn) close() error {
+	c.Lock()
+	defer c.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
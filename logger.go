@@ -0,0 +1,91 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"sync/atomic"
+)
+
+//------------------------------------------------------------------------------
+//	Structure declare
+//------------------------------------------------------------------------------
+
+type (
+	// Logger : PoolManager、Connector 等內部元件所使用的 logger，應用程式可以透過
+	// SetLogger 換成自己的實作。預設為本套件的 FieldLogger
+	Logger interface {
+		Debug(format string, v ...interface{})
+		Info(format string, v ...interface{})
+		Notice(format string, v ...interface{})
+		Warn(format string, v ...interface{})
+		Error(format string, v ...interface{})
+		Critical(format string, v ...interface{})
+
+		DebugKV(msg string, kv ...interface{})
+		InfoKV(msg string, kv ...interface{})
+		NoticeKV(msg string, kv ...interface{})
+		WarnKV(msg string, kv ...interface{})
+		ErrorKV(msg string, kv ...interface{})
+		CriticalKV(msg string, kv ...interface{})
+	}
+
+	// atomic.Value 必須存放相同型別
+	loggerHolder struct {
+		Logger
+	}
+)
+
+//------------------------------------------------------------------------------
+//	Variables
+//------------------------------------------------------------------------------
+
+var (
+	rootLogger    = &FieldLogger{}
+	currentLogger atomic.Value
+)
+
+//------------------------------------------------------------------------------
+//	Public Methods
+//------------------------------------------------------------------------------
+
+// SetLogger : 設定內部元件使用的 logger，設定後不再區分模組等級 (ModuleLogger)
+// @param	l	Logger 實作，nil 則恢復為預設
+func SetLogger(l Logger) {
+	currentLogger.Store(loggerHolder{l})
+}
+
+// GetLogger : 取得內部元件目前使用的 logger
+func GetLogger() Logger {
+	return logger(rootLogger)
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+// logger : 有 SetLogger 時使用設定的 logger，否則使用模組 logger
+func logger(module *FieldLogger) Logger {
+	if holder, ok := currentLogger.Load().(loggerHolder); ok && holder.Logger != nil {
+		return holder.Logger
+	}
+	return module
+}
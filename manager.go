@@ -61,7 +61,7 @@ func (m *Manager) Init(update bool, interval time.Duration) {
 		m.datas = make(map[interface{}]ItemInterface)
 		m.interval = interval
 	} else {
-		Error("Manager:Init: already init.")
+		logger(managerLog).Error("Manager:Init: already init.")
 		return
 	}
 	if update {
@@ -85,7 +85,7 @@ func (m *Manager) Add(key interface{}, item ItemInterface) bool {
 	m.Lock()
 	defer m.Unlock()
 	if _, ok := m.datas[key]; ok {
-		Error("Manager:Add: duplicate key. KEY=%v", key)
+		logger(managerLog).Error("Manager:Add: duplicate key. KEY=%v", key)
 		return false
 	}
 	item.OnCreate()
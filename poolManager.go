@@ -22,8 +22,11 @@ package agency
 //------------------------------------------------------------------------------
 
 import (
+	"context"
+	"fmt"
 	"reflect"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -41,11 +44,20 @@ type (
 		readyWorks          *ConcurrentQueue
 		blockWorks          *ConcurrentSet
 		activeWorkNums      *InterlockInt32
-		maxWorkNums         int32
+		maxWorkNums         *InterlockInt32
 		initialize          *InterlockBool
 		incomeWork          chan bool
 		depJobs             *ConcurrentSet
 		adminInfos          []*TaskInfo
+		infoLock            sync.RWMutex
+		stopping            bool // 已經 Shutdown，不再補上 worker，以 infoLock 保護
+		handlerStats        map[string]*handlerStats
+		statsLock           sync.RWMutex
+		workSerial          *InterlockInt64
+		jobSerial           *InterlockInt64
+		watchdog            *watchdog
+		watchdogLock        sync.Mutex
+		slowWorks           *InterlockInt64
 	}
 )
 
@@ -58,50 +70,52 @@ var (
 //	Public Methods
 //------------------------------------------------------------------------------
 
-// SendWork : 送出工作至 PoolManager 中
+// SendWork : 送出工作至 PoolManager 中。
+// handler 的第一個參數為 context.Context 且 params 不包含它時，執行時會帶入 ctx，
+// 其中有此 Task 的 trace (見 SendWorkContext)，可以再傳給 SendWorkContext、Connector.SendContext
+// 注意: handler 最後一個回傳值為 error 且不為 nil 時，Task 視為失敗 (TaskStateFailed)，
+// 以 Error 等級記錄並取消依賴它的工作 (見 SendWorkAfter)；先前的版本會忽略 handler 的回傳值
 // @param	handler	要處理的 function
 // @param	params	handler function 中所要處理的 parameters
 func (p *poolManager) SendWork(handler interface{}, params ...interface{}) *Task {
-	// check the handler, it must be a function.
-	t := reflect.TypeOf(handler)
-	if t.Kind() != reflect.Func {
-		Error("PoolManager:SendWork: handler must be a function.")
+	work := p.newWork("SendWork", handler, params)
+	if work == nil {
 		return nil
 	}
-	// gain barrier, if it exist.
-	length := len(params)
-	var b barrierBase
-	if length > 0 {
-		var ok bool
-		if b, ok = params[length-1].(barrierBase); ok {
-			length--
-		}
+	work.submit()
+	return work
+}
+
+// SendWorkContext : 同 SendWork，Task 延續 ctx 中的 trace (見 WithTrace)
+func (p *poolManager) SendWorkContext(ctx context.Context, handler interface{}, params ...interface{}) *Task {
+	work := p.newWork("SendWorkContext", handler, params)
+	if work == nil {
+		return nil
 	}
-	hval := reflect.ValueOf(handler)
-	hname := runtime.FuncForPC(hval.Pointer()).Name()
-	strs := strings.Split(hname, "/")
-	if len(strs) > 0 {
-		hname = strings.Replace(strs[len(strs)-1], "-fm", "", 1)
+	if tc, ok := TraceFromContext(ctx); ok {
+		child := tc.Child()
+		work.trace = &child
 	}
-	// check the function input parameter count.
-	if t.NumIn() != length {
-		Error("PoolManager:SendWork: function params count not current. FUNC=%s, IN_SIZE=%d, P_SIZE=%d", hname, length, t.NumIn())
+	work.submit()
+	return work
+}
+
+// SendWorkAfter : 送出工作至 PoolManager 中，並等待所有前置工作完成後才開始排隊
+// 執行。在前置工作完成前，此工作維持 TaskStateBlocked；任一前置工作失敗或取消時，
+// 此工作 (以及依賴它的後續工作) 也會一併取消，Err() 會包含前置工作的錯誤。
+// 前置工作的 handler 回傳非 nil 的 error 也視為失敗 (見 SendWork)。
+// @param	deps	前置工作
+// @param	handler	要處理的 function
+// @param	params	handler function 中所要處理的 parameters
+func (p *poolManager) SendWorkAfter(deps []*Task, handler interface{}, params ...interface{}) *Task {
+	work := p.newWork("SendWorkAfter", handler, params)
+	if work == nil {
 		return nil
 	}
-	// fill params
-	e := make([]reflect.Value, length)
-	for i := 0; i < length; i++ {
-		e[i] = reflect.ValueOf(params[i])
-	}
-	// fire in the hole!
-	work := &Task{
-		which:    -1,
-		state:    TaskStateNew,
-		handler:  hval,
-		name:     hname,
-		elems:    e,
-		checker:  b,
-		complete: false,
+	for _, dep := range deps {
+		if dep != nil {
+			work.dependOn(dep)
+		}
 	}
 	work.submit()
 	return work
@@ -111,11 +125,11 @@ func (p *poolManager) SendWork(handler interface{}, params ...interface{}) *Task
 // @param	nums	這個 PoolManager 內有多少個 Task (goroutine) 等候處理工作
 func (p *poolManager) Start(nums int) {
 	if p.initialize.Value() {
-		Error("PoolManager:Start: already start.")
+		logger(poolLog).Error("PoolManager:Start: already start.")
 		return
 	}
 
-	p.maxWorkNums = int32(nums)
+	p.maxWorkNums.Exchange(int32(nums))
 	p.workChannel = make(chan *Task, nums)
 	p.incomeWork = make(chan bool)
 	p.adminInfos = make([]*TaskInfo, nums)
@@ -132,11 +146,16 @@ func (p *poolManager) Start(nums int) {
 // Shutdown : 關閉此 PoolManager
 func (p *poolManager) Shutdown() {
 	if !p.initialize.Value() {
-		Error("PoolManager:Shutdown: not start.")
+		logger(poolLog).Error("PoolManager:Shutdown: not start.")
 		return
 	}
+	// 之後 replaceWorker 不會再對 shutdownWaitGroup 呼叫 Add
+	p.infoLock.Lock()
+	p.stopping = true
+	p.infoLock.Unlock()
 	close(p.incomeWork)
 	close(p.shutdownWorkChannel)
+	p.stopWatchdog()
 	// 停掉所有的獨立 goroutine
 	jobs := p.depJobs.ToSlice()
 	leng := len(jobs)
@@ -144,9 +163,8 @@ func (p *poolManager) Shutdown() {
 		jobs[i].(*Job).Cancel()
 	}
 	p.shutdownWaitGroup.Wait()
-	close(p.workChannel)
 
-	Notice("PoolManager:Shutdown: finish.")
+	logger(poolLog).Notice("PoolManager:Shutdown: finish.")
 }
 
 // AddLoopJob : 要求新增一個獨立執行的 goroutine，並交付給 PoolManager 管理
@@ -158,7 +176,7 @@ func (p *poolManager) AddLoopJob(handler interface{}, interval time.Duration, pa
 	// check the handler, it must be a function.
 	t := reflect.TypeOf(handler)
 	if t.Kind() != reflect.Func {
-		Error("PoolManager:AddLoopJob: handler must be a function.")
+		logger(poolLog).Error("PoolManager:AddLoopJob: handler must be a function.")
 		return nil
 	}
 	length := len(params)
@@ -171,7 +189,7 @@ func (p *poolManager) AddLoopJob(handler interface{}, interval time.Duration, pa
 
 	// check the function input parameter count.
 	if t.NumIn() != length {
-		Error("PoolManager:AddLoopJob: function params count not current. FUNC=%s, IN_SIZE=%d, P_SIZE=%d", hname, length, t.NumIn())
+		logger(poolLog).Error("PoolManager:AddLoopJob: function params count not current. FUNC=%s, IN_SIZE=%d, P_SIZE=%d", hname, length, t.NumIn())
 		return nil
 	}
 	// fill params
@@ -181,12 +199,14 @@ func (p *poolManager) AddLoopJob(handler interface{}, interval time.Duration, pa
 	}
 	// fire in the hole!
 	job := &Job{
+		id:         p.jobSerial.Increment(),
 		state:      JobStateIdle,
 		handler:    hval,
 		name:       hname,
 		interval:   interval,
 		elems:      e,
-		resumed:    make(chan bool),
+		resumed:    make(chan bool, 1),
+		cancelled:  make(chan struct{}),
 		waitGroup:  &p.shutdownWaitGroup,
 		delayStart: 0,
 	}
@@ -197,17 +217,90 @@ func (p *poolManager) AddLoopJob(handler interface{}, interval time.Duration, pa
 // GetAdminInfos : 取得 PoolManager 所管理的 goroutines 目前狀態
 // @return TaskInfo slice.
 func (p *poolManager) GetAdminInfos() []TaskInfo {
-	out := make([]TaskInfo, p.maxWorkNums)
-	for i := int32(0); i < p.maxWorkNums; i++ {
+	p.infoLock.RLock()
+	defer p.infoLock.RUnlock()
+	out := make([]TaskInfo, len(p.adminInfos))
+	for i := range p.adminInfos {
 		out[i] = *(p.adminInfos[i].clone())
 	}
 	return out
 }
 
+// GetJobs : 取得 PoolManager 所管理的 Job，依編號排序
+func (p *poolManager) GetJobs() []*Job {
+	jobs := p.depJobs.ToSlice()
+	res := make([]*Job, len(jobs))
+	for i, job := range jobs {
+		res[i] = job.(*Job)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].id < res[j].id })
+	return res
+}
+
+// FindJob : 以編號取得 Job，找不到時回傳 nil
+func (p *poolManager) FindJob(id int64) *Job {
+	jobs := p.depJobs.ToSlice()
+	for _, job := range jobs {
+		if job.(*Job).id == id {
+			return job.(*Job)
+		}
+	}
+	return nil
+}
+
 //------------------------------------------------------------------------------
 //	Private Methods
 //------------------------------------------------------------------------------
 
+func (p *poolManager) newWork(caller string, handler interface{}, params []interface{}) *Task {
+	// check the handler, it must be a function.
+	t := reflect.TypeOf(handler)
+	if t == nil || t.Kind() != reflect.Func {
+		logger(poolLog).Error("PoolManager:%s: handler must be a function.", caller)
+		return nil
+	}
+	// gain barrier, if it exist.
+	length := len(params)
+	var b barrierBase
+	if length > 0 {
+		var ok bool
+		if b, ok = params[length-1].(barrierBase); ok {
+			length--
+		}
+	}
+	hval := reflect.ValueOf(handler)
+	hname := runtime.FuncForPC(hval.Pointer()).Name()
+	strs := strings.Split(hname, "/")
+	if len(strs) > 0 {
+		hname = strings.Replace(strs[len(strs)-1], "-fm", "", 1)
+	}
+	// 第一個參數為 context.Context 時由 invoke 帶入
+	withContext := t.NumIn() == length+1 && t.In(0) == contextType
+	// check the function input parameter count.
+	if t.NumIn() != length && !withContext {
+		logger(poolLog).Error("PoolManager:%s: function params count not current. FUNC=%s, IN_SIZE=%d, P_SIZE=%d", caller, hname, length, t.NumIn())
+		return nil
+	}
+	// fill params
+	e := make([]reflect.Value, length)
+	for i := 0; i < length; i++ {
+		e[i] = reflect.ValueOf(params[i])
+	}
+	work := &Task{
+		id:       uint64(p.workSerial.Increment()),
+		which:    -1,
+		state:    TaskStateNew,
+		handler:  hval,
+		name:     hname,
+		elems:    e,
+		checker:  b,
+		complete: false,
+		context:  withContext,
+		submitAt: time.Now(),
+	}
+	return work
+}
+
 func (p *poolManager) addReadyWork(work *Task) {
 	// if IsServerDown() {
 	// 	return
@@ -236,6 +329,10 @@ func (p *poolManager) removeWorkFromBlock(work *Task) {
 }
 
 func (p *poolManager) workProcess(which int) {
+	p.infoLock.RLock()
+	info := p.adminInfos[which]
+	p.infoLock.RUnlock()
+	info.attach(goroutineID())
 	for {
 		select {
 		case <-p.shutdownWorkChannel:
@@ -244,19 +341,68 @@ func (p *poolManager) workProcess(which int) {
 
 		case work := <-p.workChannel:
 			p.activeWorkNums.Increment()
-			work.invoke(which, p.adminInfos[which])
+			work.invoke(which, info)
 			p.activeWorkNums.Decrement()
+			if info.isStuck() {
+				// 已經有替代的 worker，卸下這個 worker
+				p.retireWorker(info)
+				return
+			}
+		}
+	}
+}
+
+// replaceWorker : 將卡住的 worker 標記後補上一個新的 worker，優先使用已卸下的位置。
+// 卡住的 worker 不再列入 shutdownWaitGroup，Shutdown 不等待它結束
+// @return	新 worker 的編號，-1 表示已經 Shutdown 或已經補過
+func (p *poolManager) replaceWorker(stuck *TaskInfo) int {
+	p.infoLock.Lock()
+	defer p.infoLock.Unlock()
+	if p.stopping || !stuck.markStuck() {
+		return -1
+	}
+	which := -1
+	for i, info := range p.adminInfos {
+		if info.isRetired() {
+			which = i
+			break
 		}
 	}
+	if which == -1 {
+		which = len(p.adminInfos)
+		p.adminInfos = append(p.adminInfos, nil)
+	}
+	p.adminInfos[which] = NewTaskInfo(which)
+	p.maxWorkNums.Increment()
+	p.shutdownWaitGroup.Add(1)
+	p.shutdownWaitGroup.Done()
+	go p.workProcess(which)
+	return which
+}
+
+// retireWorker : 卡住的 worker 執行完畢後卸下，已經不在 shutdownWaitGroup 中
+func (p *poolManager) retireWorker(info *TaskInfo) {
+	info.retire()
+	p.maxWorkNums.Decrement()
+	logger(poolLog).Notice("PoolManager:retireWorker: stuck worker finished. WHICH=%d", info.Which)
 }
 
 func (p *poolManager) mainProcess() {
 	for {
 		select {
-		case <-p.incomeWork:
+		case _, ok := <-p.incomeWork:
+			if !ok {
+				// 已經 Shutdown
+				return
+			}
 		case <-time.After(time.Millisecond * 1):
-			if p.activeWorkNums.Value() < p.maxWorkNums && !p.readyWorks.Empty() {
-				p.workChannel <- p.readyWorks.Pop().(*Task)
+			if p.activeWorkNums.Value() < p.maxWorkNums.Value() && !p.readyWorks.Empty() {
+				// worker 皆已結束時不會再有人接收
+				select {
+				case p.workChannel <- p.readyWorks.Pop().(*Task):
+				case <-p.shutdownWorkChannel:
+					return
+				}
 			}
 		}
 	}
@@ -266,11 +412,14 @@ func (p *poolManager) removeJob(job *Job) {
 	p.depJobs.Remove(job)
 }
 
-func (p *poolManager) catchPanic(funcName string) {
+func (p *poolManager) catchPanic(work *Task, info *TaskInfo) {
 	if r := recover(); r != nil {
 		buf := make([]byte, 10000)
 		runtime.Stack(buf, false)
-		Critical("PANIC Defered [%v] : Stack Trace : %v", r, string(buf))
+		logger(poolLog).Critical("PANIC Defered [%v] : Stack Trace : %v", r, string(buf))
+		info.completed()
+		p.recordWork(work, workPanicked)
+		work.failed(fmt.Errorf("panic: %v", r))
 	}
 }
 
@@ -284,8 +433,12 @@ func init() {
 		readyWorks:          NewConcurrentQueue(),
 		blockWorks:          NewConcurrentSet(),
 		activeWorkNums:      NewInterlockInt32(0),
-		maxWorkNums:         0,
+		maxWorkNums:         NewInterlockInt32(0),
 		initialize:          NewInterlockBool(false),
 		depJobs:             NewConcurrentSet(),
+		handlerStats:        make(map[string]*handlerStats),
+		workSerial:          NewInterlockInt64(0),
+		jobSerial:           NewInterlockInt64(0),
+		slowWorks:           NewInterlockInt64(0),
 	}
 }
@@ -0,0 +1,252 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//	Constants
+//------------------------------------------------------------------------------
+
+const (
+	metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+//------------------------------------------------------------------------------
+//	Structure declare
+//------------------------------------------------------------------------------
+
+type (
+	promWriter struct {
+		out *bufio.Writer
+	}
+)
+
+//------------------------------------------------------------------------------
+//	Public Methods
+//------------------------------------------------------------------------------
+
+// MetricsHandler : 以 Prometheus text exposition format 輸出 metrics 的 http.Handler
+// 輸出內容包含 PoolManager、已登記的 Connector 與 Manager (RegisterConnector,
+// RegisterManager)，ex:
+//
+//	http.Handle("/metrics", agency.MetricsHandler())
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", metricsContentType)
+		if err := WriteMetrics(w); err != nil {
+			logger(adminLog).Error("MetricsHandler: write failed. ERR=%s", err.Error())
+		}
+	})
+}
+
+// WriteMetrics : 將目前的 metrics 以 Prometheus text exposition format 寫出
+func WriteMetrics(out io.Writer) error {
+	w := &promWriter{bufio.NewWriter(out)}
+	writePoolMetrics(w)
+	writeHandlerMetrics(w)
+	writeJobMetrics(w)
+	writeConnectorMetrics(w)
+	writeManagerMetrics(w)
+	return w.out.Flush()
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+func writePoolMetrics(w *promWriter) {
+	p := PoolManager
+	w.family("agency_pool_workers", "gauge", "Number of worker goroutines in the pool.")
+	w.sample("agency_pool_workers", nil, float64(p.maxWorkNums.Value()))
+	w.family("agency_pool_active_workers", "gauge", "Number of workers currently running a task.")
+	w.sample("agency_pool_active_workers", nil, float64(p.activeWorkNums.Value()))
+	w.family("agency_pool_ready_tasks", "gauge", "Number of tasks waiting for a free worker.")
+	w.sample("agency_pool_ready_tasks", nil, float64(p.readyWorks.Len()))
+	w.family("agency_pool_blocked_tasks", "gauge", "Number of tasks blocked by barriers or dependencies.")
+	w.sample("agency_pool_blocked_tasks", nil, float64(p.blockWorks.Len()))
+	w.family("agency_pool_slow_tasks_total", "counter", "Number of tasks reported by the watchdog as slow.")
+	w.sample("agency_pool_slow_tasks_total", nil, float64(p.slowWorks.Value()))
+	stuck := 0
+	for _, info := range p.GetAdminInfos() {
+		if info.Stuck {
+			stuck++
+		}
+	}
+	w.family("agency_pool_stuck_workers", "gauge", "Number of workers flagged as stuck by the watchdog.")
+	w.sample("agency_pool_stuck_workers", nil, float64(stuck))
+}
+
+func writeHandlerMetrics(w *promWriter) {
+	metrics := PoolManager.GetHandlerMetrics()
+	w.family("agency_tasks_total", "counter", "Number of tasks executed by handler and result.")
+	for _, m := range metrics {
+		ok := m.Count - m.Errors - m.Panics
+		w.sample("agency_tasks_total", []string{"handler", m.Name, "result", "ok"}, float64(ok))
+		w.sample("agency_tasks_total", []string{"handler", m.Name, "result", "error"}, float64(m.Errors))
+		w.sample("agency_tasks_total", []string{"handler", m.Name, "result", "panic"}, float64(m.Panics))
+	}
+	w.family("agency_task_queue_wait_seconds", "histogram", "Time from submit to invoke by handler.")
+	for i := range metrics {
+		w.histogram("agency_task_queue_wait_seconds", []string{"handler", metrics[i].Name}, &metrics[i].QueueWait)
+	}
+	w.family("agency_task_execution_seconds", "histogram", "Task execution time by handler.")
+	for i := range metrics {
+		w.histogram("agency_task_execution_seconds", []string{"handler", metrics[i].Name}, &metrics[i].Execution)
+	}
+	w.family("agency_task_blocked_seconds", "histogram", "Time spent blocked on barriers or dependencies by handler, only for tasks that were blocked.")
+	for i := range metrics {
+		w.histogram("agency_task_blocked_seconds", []string{"handler", metrics[i].Name}, &metrics[i].Blocked)
+	}
+}
+
+func writeJobMetrics(w *promWriter) {
+	counts := make(map[JobStateEnum]int)
+	for _, job := range PoolManager.GetJobs() {
+		counts[job.GetStatus()]++
+	}
+	w.family("agency_jobs", "gauge", "Number of loop jobs by state.")
+	for _, state := range []JobStateEnum{JobStateIdle, JobStateRun, JobStateSuspend, JobStateCancel} {
+		w.sample("agency_jobs", []string{"state", state.String()}, float64(counts[state]))
+	}
+}
+
+func writeConnectorMetrics(w *promWriter) {
+	connectors := registeredConnectors()
+	stats := make([]ConnectorStats, len(connectors))
+	for i, c := range connectors {
+		stats[i] = c.connector.GetStats()
+	}
+	w.family("agency_connector_connected", "gauge", "Whether the connector is connected.")
+	for i, c := range connectors {
+		connected := 0.0
+		if stats[i].Connected {
+			connected = 1
+		}
+		w.sample("agency_connector_connected", []string{"connector", c.name}, connected)
+	}
+	w.family("agency_connector_reconnects_total", "counter", "Number of reconnections.")
+	for i, c := range connectors {
+		w.sample("agency_connector_reconnects_total", []string{"connector", c.name}, float64(stats[i].Reconnects))
+	}
+	w.family("agency_connector_latency_seconds", "gauge", "Round-trip time of the last ping.")
+	for i, c := range connectors {
+		w.sample("agency_connector_latency_seconds", []string{"connector", c.name}, stats[i].Latency.Seconds())
+	}
+	w.family("agency_connector_queued", "gauge", "Number of commands waiting in the send queue.")
+	for i, c := range connectors {
+		w.sample("agency_connector_queued", []string{"connector", c.name}, float64(stats[i].Queued))
+	}
+	w.family("agency_connector_dropped_total", "counter", "Number of queued commands dropped without being sent.")
+	for i, c := range connectors {
+		w.sample("agency_connector_dropped_total", []string{"connector", c.name}, float64(stats[i].Dropped))
+	}
+	w.family("agency_connector_compress_bytes_total", "counter", "Body bytes of compressed commands before and after compression.")
+	for i, c := range connectors {
+		w.sample("agency_connector_compress_bytes_total", []string{"connector", c.name, "stage", "raw"}, float64(stats[i].RawBytes))
+		w.sample("agency_connector_compress_bytes_total", []string{"connector", c.name, "stage", "compressed"}, float64(stats[i].Compressed))
+	}
+	w.family("agency_connector_compress_ratio", "gauge", "Compressed size divided by raw size of compressed commands.")
+	for i, c := range connectors {
+		ratio := 1.0
+		if stats[i].RawBytes > 0 {
+			ratio = float64(stats[i].Compressed) / float64(stats[i].RawBytes)
+		}
+		w.sample("agency_connector_compress_ratio", []string{"connector", c.name}, ratio)
+	}
+	w.family("agency_connector_commands_total", "counter", "Number of commands by type and direction.")
+	for i, c := range connectors {
+		for _, cmd := range stats[i].Commands {
+			typ := strconv.FormatUint(uint64(cmd.Type), 10)
+			w.sample("agency_connector_commands_total", []string{"connector", c.name, "command", typ, "direction", "in"}, float64(cmd.InCount))
+			w.sample("agency_connector_commands_total", []string{"connector", c.name, "command", typ, "direction", "out"}, float64(cmd.OutCount))
+		}
+	}
+	w.family("agency_connector_bytes_total", "counter", "Number of bytes by command type and direction.")
+	for i, c := range connectors {
+		for _, cmd := range stats[i].Commands {
+			typ := strconv.FormatUint(uint64(cmd.Type), 10)
+			w.sample("agency_connector_bytes_total", []string{"connector", c.name, "command", typ, "direction", "in"}, float64(cmd.InBytes))
+			w.sample("agency_connector_bytes_total", []string{"connector", c.name, "command", typ, "direction", "out"}, float64(cmd.OutBytes))
+		}
+	}
+}
+
+func writeManagerMetrics(w *promWriter) {
+	w.family("agency_manager_items", "gauge", "Number of items held by the manager.")
+	for _, m := range registeredManagers() {
+		w.sample("agency_manager_items", []string{"manager", m.name}, float64(m.manager.Len()))
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func (w *promWriter) family(name, typ, help string) {
+	w.out.WriteString("# HELP " + name + " " + help + "\n")
+	w.out.WriteString("# TYPE " + name + " " + typ + "\n")
+}
+
+// sample : labels 為 key, value 交錯的 slice
+func (w *promWriter) sample(name string, labels []string, value float64) {
+	w.out.WriteString(name)
+	if len(labels) > 0 {
+		w.out.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				w.out.WriteByte(',')
+			}
+			w.out.WriteString(labels[i] + "=\"" + escapeLabel(labels[i+1]) + "\"")
+		}
+		w.out.WriteByte('}')
+	}
+	w.out.WriteByte(' ')
+	w.out.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	w.out.WriteByte('\n')
+}
+
+func (w *promWriter) histogram(name string, labels []string, h *Histogram) {
+	var total int64
+	for i, bound := range h.Bounds {
+		total += h.Counts[i]
+		le := strconv.FormatFloat(bound.Seconds(), 'g', -1, 64)
+		w.sample(name+"_bucket", append(labels[:len(labels):len(labels)], "le", le), float64(total))
+	}
+	w.sample(name+"_bucket", append(labels[:len(labels):len(labels)], "le", "+Inf"), float64(h.Count))
+	w.sample(name+"_sum", labels, float64(h.Sum)/float64(time.Second))
+	w.sample(name+"_count", labels, float64(h.Count))
+}
+
+func escapeLabel(value string) string {
+	if !strings.ContainsAny(value, "\\\"\n") {
+		return value
+	}
+	value = strings.Replace(value, "\\", "\\\\", -1)
+	value = strings.Replace(value, "\"", "\\\"", -1)
+	return strings.Replace(value, "\n", "\\n", -1)
+}
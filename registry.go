@@ -0,0 +1,104 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"sort"
+)
+
+//------------------------------------------------------------------------------
+//	Variables
+//------------------------------------------------------------------------------
+
+var (
+	// 登記給 metrics 與 admin 查詢用的物件
+	connectorRegistry = NewConcurrentMap()
+	managerRegistry   = NewConcurrentMap()
+)
+
+//------------------------------------------------------------------------------
+//	Structure declare
+//------------------------------------------------------------------------------
+
+type (
+	namedConnector struct {
+		name      string
+		connector *Connector
+	}
+
+	namedManager struct {
+		name    string
+		manager *Manager
+	}
+)
+
+//------------------------------------------------------------------------------
+//	Public Methods
+//------------------------------------------------------------------------------
+
+// RegisterConnector : 登記 Connector，以便由 metrics 與 admin 查詢
+// @param	name		顯示名稱，重複時將取代舊的
+// @param	connector	Connector 物件
+func RegisterConnector(name string, connector *Connector) {
+	connectorRegistry.Set(name, connector)
+}
+
+// UnregisterConnector : 移除登記的 Connector
+func UnregisterConnector(name string) {
+	connectorRegistry.Remove(name)
+}
+
+// RegisterManager : 登記 Manager，以便由 metrics 與 admin 查詢
+// @param	name	顯示名稱，重複時將取代舊的
+// @param	manager	Manager 物件
+func RegisterManager(name string, manager *Manager) {
+	managerRegistry.Set(name, manager)
+}
+
+// UnregisterManager : 移除登記的 Manager
+func UnregisterManager(name string) {
+	managerRegistry.Remove(name)
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+func registeredConnectors() []namedConnector {
+	pairs := connectorRegistry.GetSnapshot()
+	res := make([]namedConnector, len(pairs))
+	for i, pair := range pairs {
+		res[i] = namedConnector{pair.Key.(string), pair.Value.(*Connector)}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].name < res[j].name })
+	return res
+}
+
+func registeredManagers() []namedManager {
+	pairs := managerRegistry.GetSnapshot()
+	res := make([]namedManager, len(pairs))
+	for i, pair := range pairs {
+		res[i] = namedManager{pair.Key.(string), pair.Value.(*Manager)}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].name < res[j].name })
+	return res
+}
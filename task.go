@@ -22,9 +22,14 @@ package agency
 //------------------------------------------------------------------------------
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 )
 
 //------------------------------------------------------------------------------
@@ -45,6 +50,8 @@ const (
 	TaskStateCancel
 	// TaskStateInvoked : 事務處理中
 	TaskStateInvoked
+	// TaskStateFailed : 事務處理失敗 (panic 或回傳 error)
+	TaskStateFailed
 )
 
 //------------------------------------------------------------------------------
@@ -55,6 +62,7 @@ type (
 	// Task : 由 PoolManager 所管理的 goroutine 包裝，用來仿造 ThreadPool 內的個
 	// 別 Thread 使用
 	Task struct {
+		id       uint64          // 流水號
 		which    int             // 屬於第幾個被 PoolManager 管理的 Task 物件
 		state    TaskStateEnum   // 目前狀態
 		handler  reflect.Value   // 處理事務的 function
@@ -62,6 +70,17 @@ type (
 		elems    []reflect.Value // function parameters
 		checker  barrierBase     // 排隊用物件
 		complete bool            // 確認事務是否已處理完成
+		err      error           // 失敗或取消的原因
+		waiting  int             // 尚未完成的前置 Task 數量
+		follows  []*Task         // 等待此 Task 完成的後續 Task
+		submitAt time.Time       // 送出時間
+		blockAt  time.Time       // 開始被 barrier 或前置工作阻擋的時間
+		blocked  time.Duration   // 被 barrier 或前置工作阻擋的時間
+		invokeAt time.Time       // 開始執行時間
+		trace    *TraceContext   // SendWorkContext 時 ctx 中的 trace
+		context  bool            // handler 的第一個參數為 context.Context，由 invoke 帶入
+		queued   Span            // 送出到開始執行的 span
+		barrier  Span            // 被 barrier 阻擋的 span
 		sync.Mutex
 	}
 )
@@ -77,7 +96,14 @@ var (
 		TaskStateReady:   "TaskStateReady",
 		TaskStateCancel:  "TaskStateCancel",
 		TaskStateInvoked: "TaskStateInvoked",
+		TaskStateFailed:  "TaskStateFailed",
 	}
+
+	// ErrTaskCanceled : Task 被取消
+	ErrTaskCanceled = errors.New("task canceled")
+
+	// handler 第一個參數的型別，見 SendWork
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
 )
 
 //------------------------------------------------------------------------------
@@ -94,19 +120,56 @@ func (w *Task) Cancel() {
 		return
 	}
 	w.Lock()
-	defer w.Unlock()
 	switch w.state {
 	case TaskStateBlocked, TaskStateReady:
 		old := w.state
 		w.state = TaskStateCancel
+		w.err = ErrTaskCanceled
 		if old == TaskStateBlocked {
 			PoolManager.removeWorkFromBlock(w)
 		}
 		if w.checker != nil {
 			w.checker.cancel(w)
 		}
-		Info("Task:Cancel: NAME=%s", w.name)
+		w.endSpans(ErrTaskCanceled)
+		w.Unlock()
+		logger(poolLog).Info("Task:Cancel: NAME=%s", w.name)
+		w.notifyFollows(ErrTaskCanceled)
+		return
 	}
+	w.Unlock()
+}
+
+// ID : 取得 Task 流水號
+func (w *Task) ID() uint64 {
+	return w.id
+}
+
+// Name : 取得處理事務的 function 名稱
+func (w *Task) Name() string {
+	return w.name
+}
+
+// State : 取得目前狀態
+func (w *Task) State() TaskStateEnum {
+	w.Lock()
+	defer w.Unlock()
+	return w.state
+}
+
+// Err : 取得失敗或取消的原因，尚未結束或成功完成時回傳 nil
+func (w *Task) Err() error {
+	w.Lock()
+	defer w.Unlock()
+	return w.err
+}
+
+// Trace : 取得 Task 所屬的 trace，以 SendWorkContext 送出且 ctx 帶有 trace 才會有
+func (w *Task) Trace() (TraceContext, bool) {
+	if w.trace == nil {
+		return TraceContext{}, false
+	}
+	return *w.trace, true
 }
 
 //------------------------------------------------------------------------------
@@ -114,21 +177,113 @@ func (w *Task) Cancel() {
 //------------------------------------------------------------------------------
 
 func (w *Task) submit() {
+	w.Lock()
+	if w.state == TaskStateCancel {
+		// 前置 Task 已經失敗或取消
+		w.Unlock()
+		return
+	}
 	if w.state != TaskStateNew {
-		Error("Task:submit: failed. STAT=%s", strconv.Itoa(int(w.state)))
+		w.Unlock()
+		logger(poolLog).Error("Task:submit: failed. STAT=%s", strconv.Itoa(int(w.state)))
 		return
 	}
+	w.queued = startSpan(SpanTaskQueue, w.trace, "handler", w.name, "task", w.id)
+	if w.waiting > 0 {
+		// 等待前置 Task 完成後才進入排隊
+		w.state = TaskStateBlocked
+		w.blockAt = time.Now()
+		PoolManager.addBlockWork(w)
+		w.Unlock()
+		return
+	}
+	w.Unlock()
+	w.enqueue()
+}
+
+func (w *Task) enqueue() {
 	if w.checker != nil {
 		w.checker.setup(w)
 	}
 	w.Lock()
 	defer w.Unlock()
-	if w.canInvoke() {
-		w.state = TaskStateReady
-		PoolManager.addReadyWork(w)
-	} else {
-		w.state = TaskStateBlocked
-		PoolManager.addBlockWork(w)
+	switch w.state {
+	case TaskStateNew:
+		if w.canInvoke() {
+			w.state = TaskStateReady
+			PoolManager.addReadyWork(w)
+		} else {
+			w.state = TaskStateBlocked
+			w.blockAt = time.Now()
+			w.startBarrierSpan()
+			PoolManager.addBlockWork(w)
+		}
+	case TaskStateBlocked:
+		// 前置 Task 皆已完成
+		if w.canInvoke() {
+			w.state = TaskStateReady
+			w.unblock()
+			PoolManager.moveWorkToReady(w)
+		} else {
+			// 接著被 barrier 阻擋，繼續計時
+			w.startBarrierSpan()
+		}
+	}
+}
+
+// dependOn : 登記前置 Task，必須在 submit 之前呼叫
+func (w *Task) dependOn(prev *Task) {
+	prev.Lock()
+	defer prev.Unlock()
+	w.Lock()
+	defer w.Unlock()
+	switch {
+	case prev.complete:
+		return
+	case prev.state == TaskStateCancel, prev.state == TaskStateFailed:
+		w.state = TaskStateCancel
+		w.err = fmt.Errorf("dependency %s: %w", prev.name, prev.err)
+	default:
+		prev.follows = append(prev.follows, w)
+		w.waiting++
+	}
+}
+
+// dependDone : 前置 Task 結束時呼叫，err 不為 nil 表示前置 Task 失敗或被取消
+func (w *Task) dependDone(prev *Task, err error) {
+	w.Lock()
+	if w.state != TaskStateNew && w.state != TaskStateBlocked {
+		w.Unlock()
+		return
+	}
+	if err != nil {
+		old := w.state
+		w.state = TaskStateCancel
+		w.err = fmt.Errorf("dependency %s: %w", prev.name, err)
+		w.endSpans(w.err)
+		w.Unlock()
+		if old == TaskStateBlocked {
+			PoolManager.removeWorkFromBlock(w)
+		}
+		logger(poolLog).Info("Task:dependDone: canceled. NAME=%s, ERR=%s", w.name, w.err.Error())
+		w.notifyFollows(w.err)
+		return
+	}
+	w.waiting--
+	ready := w.waiting == 0 && w.state == TaskStateBlocked
+	w.Unlock()
+	if ready {
+		w.enqueue()
+	}
+}
+
+func (w *Task) notifyFollows(err error) {
+	w.Lock()
+	follows := w.follows
+	w.follows = nil
+	w.Unlock()
+	for _, f := range follows {
+		f.dependDone(w, err)
 	}
 }
 
@@ -144,14 +299,34 @@ func (w *Task) isCompleted() bool {
 }
 
 func (w *Task) completed() {
+	w.Lock()
 	if w.state != TaskStateInvoked {
-		Error("Task:completed: wrong state. STATE=%s", w.state.String())
+		w.Unlock()
+		logger(poolLog).Error("Task:completed: wrong state. STATE=%s", w.state.String())
 		return
 	}
 	w.complete = true
+	w.Unlock()
 	if w.checker != nil {
 		w.checker.completed(w)
 	}
+	w.notifyFollows(nil)
+}
+
+func (w *Task) failed(err error) {
+	w.Lock()
+	if w.state != TaskStateInvoked {
+		w.Unlock()
+		logger(poolLog).Error("Task:failed: wrong state. STATE=%s", w.state.String())
+		return
+	}
+	w.state = TaskStateFailed
+	w.err = err
+	w.Unlock()
+	if w.checker != nil {
+		w.checker.completed(w)
+	}
+	w.notifyFollows(err)
 }
 
 func (w *Task) invoke(which int, info *TaskInfo) {
@@ -160,17 +335,36 @@ func (w *Task) invoke(which int, info *TaskInfo) {
 	}
 	w.Lock()
 	if w.state != TaskStateReady {
-		Error("Task:invoke: wrong state. STATE=%s", w.state.String())
+		logger(poolLog).Error("Task:invoke: wrong state. STATE=%s", w.state.String())
 		w.Unlock()
 		return
 	}
 	w.which = which
 	w.state = TaskStateInvoked
+	w.invokeAt = time.Now()
+	w.endSpans(nil)
 	w.Unlock()
 	info.prepare(w.name)
-	defer PoolManager.catchPanic(w.name)
-	w.handler.Call(w.elems)
+	span := startSpan(SpanTaskExecute, w.trace, "handler", w.name, "task", w.id, "worker", which)
+	defer func() { span.End(w.Err()) }()
+	defer PoolManager.catchPanic(w, info)
+	args := w.elems
+	if w.context {
+		ctx := context.Background()
+		if tc := span.Context(); tc.IsValid() {
+			ctx = WithTrace(ctx, tc)
+		}
+		args = append([]reflect.Value{reflect.ValueOf(&ctx).Elem()}, w.elems...)
+	}
+	out := w.handler.Call(args)
 	info.completed()
+	if err := resultError(out); err != nil {
+		logger(poolLog).Error("Task:invoke: failed. NAME=%s, ERR=%s", w.name, err.Error())
+		PoolManager.recordWork(w, workErrored)
+		w.failed(err)
+		return
+	}
+	PoolManager.recordWork(w, workSucceeded)
 	w.completed()
 }
 
@@ -184,9 +378,71 @@ func (w *Task) reinvoke() {
 	case TaskStateBlocked:
 		if w.canInvoke() {
 			w.state = TaskStateReady
+			w.unblock()
+			if w.barrier != nil {
+				w.barrier.End(nil)
+				w.barrier = nil
+			}
 			PoolManager.moveWorkToReady(w)
 		}
 	case TaskStateReady, TaskStateCancel, TaskStateInvoked:
 		return
 	}
 }
+
+//------------------------------------------------------------------------------
+
+// unblock : 累計被阻擋的時間，呼叫時必須已鎖定
+func (w *Task) unblock() {
+	if !w.blockAt.IsZero() {
+		w.blocked += time.Since(w.blockAt)
+		w.blockAt = time.Time{}
+	}
+}
+
+// startBarrierSpan : 開始被 barrier 阻擋，呼叫時必須已鎖定
+func (w *Task) startBarrierSpan() {
+	if w.barrier != nil || w.checker == nil {
+		return
+	}
+	var parent TraceContext
+	if w.queued != nil {
+		parent = w.queued.Context()
+	}
+	orders := w.checker.orders()
+	tags := make([]string, len(orders))
+	for i, order := range orders {
+		tags[i] = order.tag
+	}
+	w.barrier = tracer().StartSpan(SpanTaskBarrier, parent, "handler", w.name, "task", w.id, "barrier", strings.Join(tags, ","))
+}
+
+// endSpans : 結束排隊中的 span，呼叫時必須已鎖定
+func (w *Task) endSpans(err error) {
+	if w.barrier != nil {
+		w.barrier.End(err)
+		w.barrier = nil
+	}
+	if w.queued != nil {
+		w.queued.End(err)
+		w.queued = nil
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// resultError : handler 最後一個回傳值為 error 時，取出該 error
+func resultError(out []reflect.Value) error {
+	length := len(out)
+	if length < 1 {
+		return nil
+	}
+	last := out[length-1]
+	if last.Kind() != reflect.Interface || last.IsNil() {
+		return nil
+	}
+	if err, ok := last.Interface().(error); ok {
+		return err
+	}
+	return nil
+}
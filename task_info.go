@@ -40,6 +40,7 @@ type (
 		MaxCaller    string `json:",omitempty"` // 耗費時間最長的呼叫者
 		MaxElapseStr string `json:",omitempty"` // 最大耗費執行時間：json 用
 		Total        int64  // 總執行次數
+		Stuck        bool   `json:",omitempty"` // 被 watchdog 判定卡住，已由其他 worker 替代
 	}
 
 	// TaskInfo : 工作訊息，admin 查詢用
@@ -48,6 +49,9 @@ type (
 		begin     time.Time     // 執行開始時間
 		elapse    time.Duration // 上次執行時間
 		maxElapse time.Duration // 最大耗費執行時間
+		goid      int64         // worker goroutine id
+		warned    bool          // 此次執行是否已經被 watchdog 警告過
+		retired   bool          // worker 已經卸下
 		lock      *sync.RWMutex // 互斥鎖
 		TaskOutData
 	}
@@ -80,6 +84,7 @@ func (t *TaskInfo) prepare(caller string) {
 	t.Status = "Run"
 	t.begin = time.Now()
 	t.Caller = caller
+	t.warned = false
 }
 
 func (t *TaskInfo) completed() {
@@ -104,3 +109,53 @@ func (t *TaskInfo) clone() *TaskInfo {
 	res.lock = new(sync.RWMutex)
 	return &res
 }
+
+func (t *TaskInfo) attach(goid int64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.goid = goid
+}
+
+// inspect : 檢查是否執行超過 threshold，且尚未警告過
+func (t *TaskInfo) inspect(threshold time.Duration) (caller string, elapse time.Duration, goid int64, slow bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.idle || t.warned {
+		return
+	}
+	elapse = time.Since(t.begin)
+	if elapse < threshold {
+		return
+	}
+	t.warned = true
+	return t.Caller, elapse, t.goid, true
+}
+
+func (t *TaskInfo) markStuck() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.idle || t.Stuck {
+		return false
+	}
+	t.Stuck = true
+	return true
+}
+
+func (t *TaskInfo) isStuck() bool {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.Stuck
+}
+
+func (t *TaskInfo) retire() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.retired = true
+	t.Status = "Retired"
+}
+
+func (t *TaskInfo) isRetired() bool {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.retired
+}
@@ -0,0 +1,249 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//	Constants
+//------------------------------------------------------------------------------
+
+const (
+	// 測試用 server 憑證的名稱
+	testServerName = "agency.test"
+)
+
+//------------------------------------------------------------------------------
+//	Structure declare
+//------------------------------------------------------------------------------
+
+type (
+	// testPKI : 測試用的 CA、server 與 client 憑證，以 PEM 檔案存放在暫存目錄
+	testPKI struct {
+		caFile     string
+		serverCert string
+		serverKey  string
+		clientCert string
+		clientKey  string
+	}
+)
+
+//------------------------------------------------------------------------------
+//	Tests
+//------------------------------------------------------------------------------
+
+func TestTLSRoundTrip(t *testing.T) {
+	pki := newTestPKI(t)
+	server := startTLSServer(t, pki, true)
+	server.CommandHandler = func(c *Connector, cmd *Command) {
+		c.SendCommand(cmd.Type()+1, cmd.Data())
+	}
+
+	client := newTLSConnector(t, server, pki, true, testServerName)
+	received := make(chan *Command, 1)
+	client.CommandHandler = func(cmd *Command) { received <- cmd }
+	if err := client.Connect(); err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	defer client.Disconnect()
+
+	if err := client.SendCommand(7, []byte("hello")); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	select {
+	case cmd := <-received:
+		if cmd.Type() != 8 || string(cmd.Data()) != "hello" {
+			t.Fatalf("unexpected reply. CMD=%d, DATA=%q", cmd.Type(), cmd.Data())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reply")
+	}
+
+	conns := server.Connectors()
+	if len(conns) != 1 {
+		t.Fatalf("unexpected connector count %d", len(conns))
+	}
+	if certs := conns[0].PeerCertificates(); len(certs) == 0 || certs[0].Subject.CommonName != "client" {
+		t.Fatalf("unexpected client certificates: %v", certs)
+	}
+}
+
+func TestTLSClientCertRequired(t *testing.T) {
+	pki := newTestPKI(t)
+	server := startTLSServer(t, pki, true)
+
+	client := newTLSConnector(t, server, pki, false, testServerName)
+	if err := client.Connect(); err == nil {
+		client.Disconnect()
+		t.Fatal("connect without client certificate should fail")
+	}
+	if n := len(server.Connectors()); n != 0 {
+		t.Fatalf("unexpected connector count %d", n)
+	}
+}
+
+func TestTLSServerNameMismatch(t *testing.T) {
+	pki := newTestPKI(t)
+	server := startTLSServer(t, pki, false)
+
+	client := newTLSConnector(t, server, pki, false, "other.test")
+	if err := client.Connect(); err == nil {
+		client.Disconnect()
+		t.Fatal("connect with mismatched server name should fail")
+	}
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+// newTestPKI : 建立 CA，並以此 CA 簽發 server 與 client 憑證
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	dir := t.TempDir()
+	caKey := newTestKey(t)
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "agency test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, ca, ca, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("create ca failed: %v", err)
+	}
+	if ca, err = x509.ParseCertificate(caDER); err != nil {
+		t.Fatalf("parse ca failed: %v", err)
+	}
+
+	pki := &testPKI{caFile: filepath.Join(dir, "ca.pem")}
+	writePEM(t, pki.caFile, "CERTIFICATE", caDER)
+	pki.serverCert, pki.serverKey = issueTestCert(t, dir, "server", ca, caKey, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: testServerName},
+		DNSNames:     []string{testServerName},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	pki.clientCert, pki.clientKey = issueTestCert(t, dir, "client", ca, caKey, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "client"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	return pki
+}
+
+// issueTestCert : 以 CA 簽發憑證
+// @return	憑證與私鑰的檔案位置
+func issueTestCert(t *testing.T, dir, name string, ca *x509.Certificate, caKey *ecdsa.PrivateKey, template *x509.Certificate) (string, string) {
+	t.Helper()
+	key := newTestKey(t)
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("create %s certificate failed: %v", name, err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal %s key failed: %v", name, err)
+	}
+	certFile := filepath.Join(dir, name+".pem")
+	keyFile := filepath.Join(dir, name+"-key.pem")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	return certFile, keyFile
+}
+
+func newTestKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key failed: %v", err)
+	}
+	return key
+}
+
+func writePEM(t *testing.T, file, kind string, der []byte) {
+	t.Helper()
+	if err := ioutil.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0600); err != nil {
+		t.Fatalf("write %s failed: %v", file, err)
+	}
+}
+
+// startTLSServer : 在隨機 port 啟動 wss 的 ConnectorServer
+// @param	mutual	是否要求 client 憑證
+func startTLSServer(t *testing.T, pki *testPKI, mutual bool) *ConnectorServer {
+	t.Helper()
+	clientCA := ""
+	if mutual {
+		clientCA = pki.caFile
+	}
+	config, err := NewServerTLSConfig(pki.serverCert, pki.serverKey, clientCA)
+	if err != nil {
+		t.Fatalf("server tls config failed: %v", err)
+	}
+	server := NewConnectorServer("127.0.0.1:0")
+	server.TLSConfig = config
+	if err := server.Start(); err != nil {
+		t.Fatalf("start server failed: %v", err)
+	}
+	t.Cleanup(server.Shutdown)
+	return server
+}
+
+// newTLSConnector : 建立連往 server 的 Connector
+// @param	withCert	是否帶 client 憑證
+// @param	serverName	驗證 server 憑證的名稱
+func newTLSConnector(t *testing.T, server *ConnectorServer, pki *testPKI, withCert bool, serverName string) *Connector {
+	t.Helper()
+	certFile, keyFile := "", ""
+	if withCert {
+		certFile, keyFile = pki.clientCert, pki.clientKey
+	}
+	tlsConfig, err := NewClientTLSConfig(pki.caFile, certFile, keyFile, serverName)
+	if err != nil {
+		t.Fatalf("client tls config failed: %v", err)
+	}
+	config := DefaultConnectorConfig(server.Address())
+	config.DialTimeout = 5 * time.Second
+	config.TLSConfig = tlsConfig
+	config.Handshake = &HandshakeData{Service: "tls_test"}
+	return NewConnector(config)
+}
@@ -0,0 +1,182 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+//	Constants
+//------------------------------------------------------------------------------
+
+const (
+	// W3C traceparent 版本
+	traceparentVersion = "00"
+	// TraceFlagSampled : W3C trace-flags 的 sampled 位元
+	TraceFlagSampled byte = 0x01
+	// 封包中 trace 資料的長度: trace id(16) + span id(8) + flags(1)
+	traceWireSize = 25
+	// FeatureTrace : 命令表頭後帶有 trace 資料，見 ProtocolVersion
+	FeatureTrace = "trace"
+)
+
+//------------------------------------------------------------------------------
+//	Structure declare
+//------------------------------------------------------------------------------
+
+type (
+	// TraceContext : 追蹤資訊，格式與 W3C traceparent 相容
+	TraceContext struct {
+		TraceID [16]byte // 整個呼叫鏈共用
+		SpanID  [8]byte  // 目前這一段
+		Flags   byte     // trace-flags
+	}
+
+	// context.Context 中存放 TraceContext 的 key
+	traceContextKey struct{}
+)
+
+//------------------------------------------------------------------------------
+//	Public Methods
+//------------------------------------------------------------------------------
+
+// NewTraceContext : 產生新的 trace id 與 span id
+func NewTraceContext() TraceContext {
+	var tc TraceContext
+	rand.Read(tc.TraceID[:])
+	rand.Read(tc.SpanID[:])
+	tc.Flags = TraceFlagSampled
+	return tc
+}
+
+// ParseTraceparent : 解析 W3C traceparent，ex: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+func ParseTraceparent(str string) (TraceContext, error) {
+	var tc TraceContext
+	parts := strings.Split(strings.TrimSpace(str), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" ||
+		len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return tc, errors.New("invalid traceparent: " + str)
+	}
+	// W3C 只允許小寫的 hex
+	for _, part := range parts[:4] {
+		if !isLowerHex(part) {
+			return tc, errors.New("invalid traceparent: " + str)
+		}
+	}
+	if parts[0] == traceparentVersion && len(parts) != 4 {
+		return tc, errors.New("invalid traceparent: " + str)
+	}
+	var flags [1]byte
+	if _, err := hex.Decode(tc.TraceID[:], []byte(parts[1])); err != nil {
+		return tc, errors.New("invalid traceparent: " + str)
+	}
+	if _, err := hex.Decode(tc.SpanID[:], []byte(parts[2])); err != nil {
+		return tc, errors.New("invalid traceparent: " + str)
+	}
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return tc, errors.New("invalid traceparent: " + str)
+	}
+	tc.Flags = flags[0]
+	if !tc.IsValid() {
+		return tc, errors.New("invalid traceparent: " + str)
+	}
+	return tc, nil
+}
+
+// IsValid : trace id 與 span id 皆不為 0
+func (t TraceContext) IsValid() bool {
+	return t.TraceID != [16]byte{} && t.SpanID != [8]byte{}
+}
+
+// String : W3C traceparent 格式
+func (t TraceContext) String() string {
+	return traceparentVersion + "-" + t.TraceIDString() + "-" + t.SpanIDString() + "-" + hex.EncodeToString([]byte{t.Flags})
+}
+
+// TraceIDString : 32 個字元的 trace id
+func (t TraceContext) TraceIDString() string {
+	return hex.EncodeToString(t.TraceID[:])
+}
+
+// SpanIDString : 16 個字元的 span id
+func (t TraceContext) SpanIDString() string {
+	return hex.EncodeToString(t.SpanID[:])
+}
+
+// Child : 同一個 trace 下產生新的 span id
+func (t TraceContext) Child() TraceContext {
+	rand.Read(t.SpanID[:])
+	return t
+}
+
+// WithTrace : 將 trace 放入 ctx，SendContext、SendWorkContext 等帶有 ctx 的呼叫會延續此 trace
+func WithTrace(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, tc)
+}
+
+// TraceFromContext : 取得 ctx 中的 trace
+func TraceFromContext(ctx context.Context) (TraceContext, bool) {
+	if ctx == nil {
+		return TraceContext{}, false
+	}
+	tc, ok := ctx.Value(traceContextKey{}).(TraceContext)
+	return tc, ok && tc.IsValid()
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+func (t TraceContext) encode(data []byte) {
+	copy(data[0:16], t.TraceID[:])
+	copy(data[16:24], t.SpanID[:])
+	data[24] = t.Flags
+}
+
+func decodeTrace(data []byte) TraceContext {
+	var tc TraceContext
+	copy(tc.TraceID[:], data[0:16])
+	copy(tc.SpanID[:], data[16:24])
+	tc.Flags = data[24]
+	return tc
+}
+
+// traceFields : 將 trace 加到 log 欄位之後
+func traceFields(fields []interface{}, tc TraceContext) []interface{} {
+	res := make([]interface{}, 0, len(fields)+4)
+	res = append(res, fields...)
+	return append(res, "trace_id", tc.TraceIDString(), "span_id", tc.SpanIDString())
+}
+
+func isLowerHex(str string) bool {
+	for i := 0; i < len(str); i++ {
+		if c := str[i]; (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
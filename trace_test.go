@@ -0,0 +1,107 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//	Variables
+//------------------------------------------------------------------------------
+
+var (
+	// 測試共用的 PoolManager 只啟動一次
+	testPoolOnce sync.Once
+)
+
+//------------------------------------------------------------------------------
+//	Tests
+//------------------------------------------------------------------------------
+
+func TestParseTraceparent(t *testing.T) {
+	tc, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if tc.String() != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Fatalf("unexpected traceparent %s", tc.String())
+	}
+	for _, str := range []string{
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00F067AA0BA902B7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0A",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		if _, err := ParseTraceparent(str); err == nil {
+			t.Errorf("%s should be rejected", str)
+		}
+	}
+}
+
+func TestSendWorkContextTrace(t *testing.T) {
+	startTestPool()
+	parent := NewTraceContext()
+	got := make(chan context.Context, 1)
+	task := PoolManager.SendWorkContext(WithTrace(context.Background(), parent), func(ctx context.Context, n int) {
+		got <- ctx
+	}, 1)
+	if task == nil {
+		t.Fatal("send work failed")
+	}
+	select {
+	case ctx := <-got:
+		tc, ok := TraceFromContext(ctx)
+		if !ok || tc.TraceID != parent.TraceID || tc.SpanID == parent.SpanID {
+			t.Fatalf("unexpected trace %v, parent %v", tc, parent)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler not invoked")
+	}
+
+	// 沒有 trace 時仍然帶入 ctx
+	PoolManager.SendWork(func(ctx context.Context) { got <- ctx })
+	select {
+	case ctx := <-got:
+		if _, ok := TraceFromContext(ctx); ok || ctx == nil {
+			t.Fatal("unexpected trace in ctx")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler not invoked")
+	}
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+// startTestPool : 啟動測試共用的 PoolManager，不會關閉
+func startTestPool() {
+	testPoolOnce.Do(func() {
+		PoolManager.Start(4)
+	})
+}
@@ -0,0 +1,246 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//	Constants
+//------------------------------------------------------------------------------
+
+const (
+	// 內建的 span 名稱
+	SpanCommandReceive = "command.receive" // Connector 收到命令到 CommandHandler 結束
+	SpanCommandSend    = "command.send"    // Connector 送出命令
+	SpanTaskQueue      = "task.queue"      // Task 送出到開始執行
+	SpanTaskBarrier    = "task.barrier"    // Task 被 barrier 阻擋
+	SpanTaskExecute    = "task.execute"    // handler 執行
+)
+
+//------------------------------------------------------------------------------
+//	Variables
+//------------------------------------------------------------------------------
+
+var (
+	currentTracer atomic.Value
+	// 沒有上層時的 noopSpan，避免每次轉型配置記憶體
+	emptySpan Span = noopSpan{}
+)
+
+//------------------------------------------------------------------------------
+//	Structure declare
+//------------------------------------------------------------------------------
+
+type (
+	// Tracer : span 的產生者，可以透過 SetTracer 接到 OpenTelemetry 等系統
+	Tracer interface {
+		// StartSpan : 開始一個 span
+		// @param	name	span 名稱，內建的見 SpanCommandReceive 等常數
+		// @param	parent	上層的 trace，IsValid() 為 false 表示沒有上層
+		// @param	kv		key, value 交錯的屬性
+		StartSpan(name string, parent TraceContext, kv ...interface{}) Span
+	}
+
+	// Span : 一段有開始與結束的工作
+	Span interface {
+		// Context : 此 span 的 trace，之後的 Task 與命令會以此為上層
+		Context() TraceContext
+		// SetAttributes : 加上屬性
+		SetAttributes(kv ...interface{})
+		// End : 結束，err 不為 nil 表示失敗
+		End(err error)
+	}
+
+	// SpanData : SpanRecorder 所記錄的 span
+	SpanData struct {
+		Name       string                 // span 名稱
+		Context    TraceContext           // 此 span 的 trace
+		Parent     TraceContext           // 上層的 trace
+		Attributes map[string]interface{} // 屬性
+		Start      time.Time              // 開始時間
+		End        time.Time              // 結束時間
+		Err        error                  // 失敗原因
+	}
+
+	// SpanRecorder : 將結束的 span 保留在記憶體中的 Tracer，測試用
+	SpanRecorder struct {
+		spans []SpanData
+		sync.Mutex
+	}
+
+	recordedSpan struct {
+		recorder *SpanRecorder
+		data     SpanData
+		ended    bool
+		sync.Mutex
+	}
+
+	// noopTracer : 預設的 Tracer，不做任何事
+	noopTracer struct{}
+
+	noopSpan struct {
+		context TraceContext
+	}
+
+	// atomic.Value 必須存放相同型別
+	tracerHolder struct {
+		Tracer
+	}
+)
+
+//------------------------------------------------------------------------------
+//	Public Methods
+//------------------------------------------------------------------------------
+
+// SetTracer : 設定 Connector、PoolManager 與 Task 使用的 Tracer
+// @param	t	Tracer 實作，nil 則恢復為不做任何事的預設值
+func SetTracer(t Tracer) {
+	currentTracer.Store(tracerHolder{t})
+}
+
+// GetTracer : 取得目前使用的 Tracer
+func GetTracer() Tracer {
+	return tracer()
+}
+
+//------------------------------------------------------------------------------
+
+// NewSpanRecorder : SpanRecorder object creator.
+func NewSpanRecorder() *SpanRecorder {
+	return &SpanRecorder{}
+}
+
+// StartSpan : implement Tracer
+func (r *SpanRecorder) StartSpan(name string, parent TraceContext, kv ...interface{}) Span {
+	context := NewTraceContext()
+	if parent.IsValid() {
+		context = parent.Child()
+	}
+	span := &recordedSpan{
+		recorder: r,
+		data: SpanData{
+			Name:       name,
+			Context:    context,
+			Parent:     parent,
+			Attributes: make(map[string]interface{}),
+			Start:      time.Now(),
+		},
+	}
+	span.SetAttributes(kv...)
+	return span
+}
+
+// Spans : 取得已結束的 span，依結束順序
+func (r *SpanRecorder) Spans() []SpanData {
+	r.Lock()
+	defer r.Unlock()
+	res := make([]SpanData, len(r.spans))
+	copy(res, r.spans)
+	return res
+}
+
+// Reset : 清除已記錄的 span
+func (r *SpanRecorder) Reset() {
+	r.Lock()
+	defer r.Unlock()
+	r.spans = nil
+}
+
+// Context : implement Span
+func (s *recordedSpan) Context() TraceContext {
+	return s.data.Context
+}
+
+// SetAttributes : implement Span
+func (s *recordedSpan) SetAttributes(kv ...interface{}) {
+	s.Lock()
+	defer s.Unlock()
+	kv = copyFields(nil, kv)
+	for i := 0; i < len(kv); i += 2 {
+		s.data.Attributes[fieldKey(kv[i])] = kv[i+1]
+	}
+}
+
+// End : implement Span，重複呼叫無效
+func (s *recordedSpan) End(err error) {
+	s.Lock()
+	if s.ended {
+		s.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	s.data.Err = err
+	data := s.data
+	s.Unlock()
+	s.recorder.Lock()
+	s.recorder.spans = append(s.recorder.spans, data)
+	s.recorder.Unlock()
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+func tracer() Tracer {
+	if holder, ok := currentTracer.Load().(tracerHolder); ok && holder.Tracer != nil {
+		return holder.Tracer
+	}
+	return noopTracer{}
+}
+
+// startSpan : 開始 span，parent 為 nil 表示沒有上層
+func startSpan(name string, parent *TraceContext, kv ...interface{}) Span {
+	var tc TraceContext
+	if parent != nil {
+		tc = *parent
+	}
+	t := tracer()
+	if _, ok := t.(noopTracer); ok && parent == nil {
+		return emptySpan
+	}
+	return t.StartSpan(name, tc, kv...)
+}
+
+// tracing : 是否設定了 Tracer，未設定時可以省略 span 屬性的準備
+func tracing() bool {
+	_, noop := tracer().(noopTracer)
+	return !noop
+}
+
+//------------------------------------------------------------------------------
+
+func (t noopTracer) StartSpan(name string, parent TraceContext, kv ...interface{}) Span {
+	return noopSpan{parent}
+}
+
+func (s noopSpan) Context() TraceContext {
+	return s.context
+}
+
+func (s noopSpan) SetAttributes(kv ...interface{}) {}
+
+func (s noopSpan) End(err error) {}
@@ -0,0 +1,205 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//	Constants
+//------------------------------------------------------------------------------
+
+const (
+	// 取得全部 goroutine stack 時的 buffer 上限
+	maxStackDumpSize = 8 << 20
+)
+
+//------------------------------------------------------------------------------
+//	Structure declare
+//------------------------------------------------------------------------------
+
+type (
+	// WatchdogConfig : watchdog 設定
+	WatchdogConfig struct {
+		Interval  time.Duration      // 檢查週期，0 則為 Threshold 的一半
+		Threshold time.Duration      // 執行時間超過此值視為緩慢
+		OnSlow    func(SlowTaskData) // 發現緩慢的 Task 時呼叫，可為 nil
+		Replace   bool               // 將緩慢的 worker 標記為卡住，並補上一個新的 worker；卡住的 worker 不列入 Shutdown 的等待
+	}
+
+	// SlowTaskData : 執行時間過長的 Task 資訊
+	SlowTaskData struct {
+		Which  int           // 第幾個 worker
+		Caller string        // handler 名稱
+		Elapse time.Duration // 目前已執行時間
+		Stack  string        // 該 worker 的 goroutine stack
+	}
+
+	// watchdog : 在獨立的 goroutine 上定期檢查，不列入 PoolManager 的 Job
+	watchdog struct {
+		config WatchdogConfig
+		stop   chan struct{}
+	}
+)
+
+//------------------------------------------------------------------------------
+//	Public Methods
+//------------------------------------------------------------------------------
+
+// StartWatchdog : 啟動 watchdog，定期檢查執行中的 Task 是否超過時間
+// @param	config	watchdog 設定
+func (p *poolManager) StartWatchdog(config WatchdogConfig) error {
+	if config.Threshold <= 0 {
+		return errors.New("watchdog threshold must be positive")
+	}
+	if config.Interval <= 0 {
+		config.Interval = config.Threshold / 2
+	}
+	p.watchdogLock.Lock()
+	defer p.watchdogLock.Unlock()
+	if p.watchdog != nil {
+		return errors.New("watchdog already started")
+	}
+	w := &watchdog{config: config, stop: make(chan struct{})}
+	p.watchdog = w
+	go p.watchdogProcess(w)
+	logger(poolLog).Notice("PoolManager:StartWatchdog: THRESHOLD=%s, INTERVAL=%s", config.Threshold.String(), config.Interval.String())
+	return nil
+}
+
+// StopWatchdog : 停止 watchdog
+func (p *poolManager) StopWatchdog() {
+	if !p.stopWatchdog() {
+		logger(poolLog).Error("PoolManager:StopWatchdog: not start.")
+	}
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+// stopWatchdog : 停止 watchdog
+// @return	是否有執行中的 watchdog
+func (p *poolManager) stopWatchdog() bool {
+	p.watchdogLock.Lock()
+	defer p.watchdogLock.Unlock()
+	if p.watchdog == nil {
+		return false
+	}
+	close(p.watchdog.stop)
+	p.watchdog = nil
+	return true
+}
+
+func (p *poolManager) watchdogProcess(w *watchdog) {
+	ticker := time.NewTicker(w.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			p.checkSlowWorks(w)
+		}
+	}
+}
+
+func (p *poolManager) checkSlowWorks(w *watchdog) {
+	p.infoLock.RLock()
+	infos := make([]*TaskInfo, len(p.adminInfos))
+	copy(infos, p.adminInfos)
+	p.infoLock.RUnlock()
+
+	var stacks []byte
+	for _, info := range infos {
+		caller, elapse, goid, slow := info.inspect(w.config.Threshold)
+		if !slow {
+			continue
+		}
+		if stacks == nil {
+			stacks = allStacks()
+		}
+		data := SlowTaskData{
+			Which:  info.Which,
+			Caller: caller,
+			Elapse: elapse,
+			Stack:  goroutineStack(stacks, goid),
+		}
+		p.slowWorks.Increment()
+		logger(poolLog).Warn("PoolManager:checkSlowWorks: slow task. WHICH=%d, NAME=%s, ELAPSE=%s\n%s", data.Which, data.Caller, data.Elapse.String(), data.Stack)
+		if w.config.OnSlow != nil {
+			w.config.OnSlow(data)
+		}
+		if !w.config.Replace {
+			continue
+		}
+		if which := p.replaceWorker(info); which >= 0 {
+			logger(poolLog).Warn("PoolManager:checkSlowWorks: worker stuck, spawn replacement. WHICH=%d, NEW=%d", data.Which, which)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// goroutineID : 取得目前 goroutine 的 id
+func goroutineID() int64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	// "goroutine 123 [running]: ..."
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	if i := bytes.IndexByte(buf, ' '); i > 0 {
+		buf = buf[:i]
+	}
+	id, _ := strconv.ParseInt(string(buf), 10, 64)
+	return id
+}
+
+func allStacks() []byte {
+	size := 64 << 10
+	for {
+		buf := make([]byte, size)
+		n := runtime.Stack(buf, true)
+		if n < size || size >= maxStackDumpSize {
+			return buf[:n]
+		}
+		size *= 2
+	}
+}
+
+// goroutineStack : 從全部的 stack 中取出指定 goroutine 的部分
+func goroutineStack(stacks []byte, goid int64) string {
+	head := []byte("goroutine " + strconv.FormatInt(goid, 10) + " [")
+	begin := bytes.Index(stacks, head)
+	if begin == -1 {
+		return ""
+	}
+	end := bytes.Index(stacks[begin:], []byte("\n\n"))
+	if end == -1 {
+		return string(stacks[begin:])
+	}
+	return string(stacks[begin : begin+end])
+}
@@ -0,0 +1,173 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"fmt"
+)
+
+//------------------------------------------------------------------------------
+//	Structure declare
+//------------------------------------------------------------------------------
+
+type (
+	// Workflow : 一組有相依關係的工作 (DAG)，可以一次送進 PoolManager 中
+	//	wf := NewWorkflow()
+	//	wf.Step("profile", UpdateProfile, player, MakeBarrier(player))
+	//	wf.Step("vip", RecomputeVip, player, MakeBarrier(player)).After("vip", "profile")
+	//	wf.Step("notify", Notify, player).After("notify", "vip")
+	//	tasks, err := wf.Submit()
+	Workflow struct {
+		steps map[string]*workflowStep
+		order []string // 加入順序
+		err   error    // 建立過程中的第一個錯誤
+	}
+
+	workflowStep struct {
+		name    string
+		handler interface{}
+		params  []interface{}
+		after   []string
+	}
+)
+
+//------------------------------------------------------------------------------
+//	Public Methods
+//------------------------------------------------------------------------------
+
+// NewWorkflow : Workflow object creator.
+func NewWorkflow() *Workflow {
+	return &Workflow{
+		steps: make(map[string]*workflowStep),
+	}
+}
+
+// Step : 新增一個工作步驟
+// @param	name	步驟名稱，在 Workflow 中必須唯一
+// @param	handler	要處理的 function
+// @param	params	handler function 中所要處理的 parameters，最後一個可以是 barrier
+func (w *Workflow) Step(name string, handler interface{}, params ...interface{}) *Workflow {
+	if _, ok := w.steps[name]; ok {
+		w.fail(fmt.Errorf("duplicate step %q", name))
+		return w
+	}
+	w.steps[name] = &workflowStep{
+		name:    name,
+		handler: handler,
+		params:  params,
+	}
+	w.order = append(w.order, name)
+	return w
+}
+
+// After : 指定步驟必須等待哪些步驟完成後才能執行
+// @param	name	步驟名稱
+// @param	deps	前置步驟名稱
+func (w *Workflow) After(name string, deps ...string) *Workflow {
+	step, ok := w.steps[name]
+	if !ok {
+		w.fail(fmt.Errorf("unknown step %q", name))
+		return w
+	}
+	step.after = append(step.after, deps...)
+	return w
+}
+
+// Submit : 檢查相依關係後將所有步驟送至 PoolManager
+// @return	步驟名稱對應的 Task 物件 & error，有錯誤時不會送出任何步驟
+func (w *Workflow) Submit() (map[string]*Task, error) {
+	if w.err != nil {
+		return nil, w.err
+	}
+	sorted, err := w.sort()
+	if err != nil {
+		return nil, err
+	}
+	// 先建立全部的 Task，確保不會只送出一半的工作
+	tasks := make(map[string]*Task, len(sorted))
+	for _, step := range sorted {
+		work := PoolManager.newWork("Workflow", step.handler, step.params)
+		if work == nil {
+			return nil, fmt.Errorf("invalid step %q", step.name)
+		}
+		tasks[step.name] = work
+	}
+	for _, step := range sorted {
+		work := tasks[step.name]
+		for _, dep := range step.after {
+			work.dependOn(tasks[dep])
+		}
+		work.submit()
+	}
+	return tasks, nil
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+func (w *Workflow) fail(err error) {
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+// sort : 依相依關係排序 (Kahn's algorithm)，同層依加入順序
+func (w *Workflow) sort() ([]*workflowStep, error) {
+	degree := make(map[string]int, len(w.steps))
+	nexts := make(map[string][]string, len(w.steps))
+	for _, name := range w.order {
+		step := w.steps[name]
+		for _, dep := range step.after {
+			if _, ok := w.steps[dep]; !ok {
+				return nil, fmt.Errorf("step %q depends on unknown step %q", name, dep)
+			}
+			if dep == name {
+				return nil, fmt.Errorf("step %q depends on itself", name)
+			}
+			degree[name]++
+			nexts[dep] = append(nexts[dep], name)
+		}
+	}
+	res := make([]*workflowStep, 0, len(w.order))
+	queue := make([]string, 0, len(w.order))
+	for _, name := range w.order {
+		if degree[name] == 0 {
+			queue = append(queue, name)
+		}
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		res = append(res, w.steps[name])
+		for _, next := range nexts[name] {
+			degree[next]--
+			if degree[next] == 0 {
+				queue = append(queue, next)
+			}
+		}
+	}
+	if len(res) != len(w.order) {
+		return nil, fmt.Errorf("workflow has a dependency cycle")
+	}
+	return res, nil
+}
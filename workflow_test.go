@@ -0,0 +1,184 @@
+//------------------------------------------------------------------------------
+//
+//  Copyright 2020 by International Games System Co., Ltd.
+//  All rights reserved.
+//
+//  This software is the confidential and proprietary information of
+//  International Game System Co., Ltd. ('Confidential Information'). You shall
+//  not disclose such Confidential Information and shall use it only in
+//  accordance with the terms of the license agreement you entered into with
+//  International Game System Co., Ltd.
+//
+//------------------------------------------------------------------------------
+
+//------------------------------------------------------------------------------
+//	Package declare
+//------------------------------------------------------------------------------
+
+package agency
+
+//------------------------------------------------------------------------------
+//	Import packages
+//------------------------------------------------------------------------------
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//	Tests
+//------------------------------------------------------------------------------
+
+func TestSendWorkAfterOrder(t *testing.T) {
+	startTestPool()
+	gate := make(chan struct{})
+	order := make(chan string, 2)
+	first := PoolManager.SendWork(func() {
+		<-gate
+		order <- "first"
+	})
+	second := PoolManager.SendWorkAfter([]*Task{first}, func() { order <- "second" })
+	if second == nil {
+		t.Fatal("send work failed")
+	}
+	if state := second.State(); state != TaskStateBlocked {
+		t.Fatalf("unexpected state %s before dependency completed", state.String())
+	}
+	close(gate)
+	for _, want := range []string{"first", "second"} {
+		select {
+		case got := <-order:
+			if got != want {
+				t.Fatalf("unexpected order. GOT=%s, WANT=%s", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s not invoked", want)
+		}
+	}
+}
+
+func TestSendWorkAfterFailure(t *testing.T) {
+	startTestPool()
+	errFailed := errors.New("failed")
+	gate := make(chan struct{})
+	invoked := make(chan struct{}, 2)
+	first := PoolManager.SendWork(func() error {
+		<-gate
+		return errFailed
+	})
+	second := PoolManager.SendWorkAfter([]*Task{first}, func() { invoked <- struct{}{} })
+	third := PoolManager.SendWorkAfter([]*Task{second}, func() { invoked <- struct{}{} })
+	close(gate)
+
+	waitTaskState(t, first, TaskStateFailed)
+	if err := first.Err(); err != errFailed {
+		t.Fatalf("unexpected error %v", err)
+	}
+	for _, task := range []*Task{second, third} {
+		waitTaskState(t, task, TaskStateCancel)
+		if err := task.Err(); !errors.Is(err, errFailed) {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	// 前置工作已失敗時，之後送出的工作直接取消
+	late := PoolManager.SendWorkAfter([]*Task{first}, func() { invoked <- struct{}{} })
+	if state := late.State(); state != TaskStateCancel {
+		t.Fatalf("unexpected state %s", state.String())
+	}
+	select {
+	case <-invoked:
+		t.Fatal("dependent of a failed task invoked")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSendWorkAfterCancel(t *testing.T) {
+	startTestPool()
+	gate := make(chan struct{})
+	defer close(gate)
+	invoked := make(chan struct{}, 2)
+	running := PoolManager.SendWork(func() { <-gate })
+	blocked := PoolManager.SendWorkAfter([]*Task{running}, func() { invoked <- struct{}{} })
+	follow := PoolManager.SendWorkAfter([]*Task{blocked}, func() { invoked <- struct{}{} })
+
+	blocked.Cancel()
+	waitTaskState(t, blocked, TaskStateCancel)
+	waitTaskState(t, follow, TaskStateCancel)
+	if err := follow.Err(); !errors.Is(err, ErrTaskCanceled) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	select {
+	case <-invoked:
+		t.Fatal("canceled task invoked")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestWorkflowSubmit(t *testing.T) {
+	startTestPool()
+	order := make(chan string, 3)
+	step := func(name string) { order <- name }
+	tasks, err := NewWorkflow().
+		Step("notify", step, "notify").
+		Step("vip", step, "vip").
+		Step("profile", step, "profile").
+		After("notify", "vip").
+		After("vip", "profile").
+		Submit()
+	if err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+	if len(tasks) != 3 {
+		t.Fatalf("unexpected task count %d", len(tasks))
+	}
+	for _, want := range []string{"profile", "vip", "notify"} {
+		select {
+		case got := <-order:
+			if got != want {
+				t.Fatalf("unexpected order. GOT=%s, WANT=%s", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s not invoked", want)
+		}
+	}
+}
+
+func TestWorkflowReject(t *testing.T) {
+	invoked := make(chan struct{}, 3)
+	step := func() { invoked <- struct{}{} }
+	for name, wf := range map[string]*Workflow{
+		"cycle": NewWorkflow().
+			Step("a", step).Step("b", step).Step("c", step).
+			After("a", "c").After("b", "a").After("c", "b"),
+		"self":      NewWorkflow().Step("a", step).After("a", "a"),
+		"unknown":   NewWorkflow().Step("a", step).After("a", "b"),
+		"duplicate": NewWorkflow().Step("a", step).Step("a", step),
+	} {
+		if tasks, err := wf.Submit(); err == nil || tasks != nil {
+			t.Errorf("%s should be rejected", name)
+		}
+	}
+	select {
+	case <-invoked:
+		t.Fatal("step of a rejected workflow invoked")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+//------------------------------------------------------------------------------
+//	Private Methods
+//------------------------------------------------------------------------------
+
+// waitTaskState : 等待 Task 進入指定狀態
+func waitTaskState(t *testing.T, task *Task, state TaskStateEnum) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for current := task.State(); current != state; current = task.State() {
+		if time.Now().After(deadline) {
+			t.Fatalf("task %s not %s. STATE=%s", task.Name(), state.String(), current.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}